@@ -4,9 +4,12 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"strings"
 	"time"
+	_ "time/tzdata" // База часовых поясов для CRON_TZ, если в системе ее нет
 
 	"github.com/ViolettaBykova/viot-tg-sirius/handlers"
+	"github.com/ViolettaBykova/viot-tg-sirius/pkg/telegram"
 	"github.com/ViolettaBykova/viot-tg-sirius/pkg/weather"
 	botservice "github.com/ViolettaBykova/viot-tg-sirius/services/bot"
 	"github.com/ViolettaBykova/viot-tg-sirius/storage/postgres"
@@ -51,22 +54,150 @@ func main() {
 	if err != nil {
 		log.Fatalf("Failed to create bot: %v\n", err)
 	}
+	// Все сообщения уходят через очередь, которая соблюдает ограничения Telegram на частоту отправки.
+	// TELEGRAM_SEND_RATE — предел для всего бота: очередь у каждого экземпляра своя, поэтому он делится
+	// поровну между TELEGRAM_REPLICAS экземплярами, работающими одновременно.
+	viper.SetDefault("TELEGRAM_SEND_RATE", telegram.DefaultGlobalRate)
+	viper.SetDefault("TELEGRAM_REPLICAS", 1)
+	viper.SetDefault("TELEGRAM_CHAT_INTERVAL", telegram.DefaultChatInterval)
+	sendRate := max(viper.GetInt("TELEGRAM_SEND_RATE")/max(viper.GetInt("TELEGRAM_REPLICAS"), 1), 1)
+	sendQueue := telegram.NewQueue(bot,
+		telegram.WithGlobalRate(sendRate),
+		telegram.WithChatInterval(viper.GetDuration("TELEGRAM_CHAT_INTERVAL")),
+	)
+	sendQueue.Start()
 	cronScheduler := cron.New()
 	apiKey := viper.GetString("OPENWEATHER_API_KEY")
-	weatherClient := weather.New(apiKey)
+	viper.SetDefault("WEATHER_HTTP_TIMEOUT", 10*time.Second)
+	viper.SetDefault("WEATHER_MAX_RETRIES", 3)
+	viper.SetDefault("OPENWEATHER_RATE_LIMIT", 60) // Запросов в минуту, бесплатный тариф OpenWeatherMap
+	transportOpts := []weather.Option{
+		weather.WithTimeout(viper.GetDuration("WEATHER_HTTP_TIMEOUT")),
+		weather.WithRetries(viper.GetInt("WEATHER_MAX_RETRIES"), 500*time.Millisecond, 30*time.Second),
+	}
+	weatherClient := weather.New(apiKey, append(transportOpts,
+		weather.WithRateLimit(viper.GetInt("OPENWEATHER_RATE_LIMIT"), 10),
+	)...)
+
+	// Open-Meteo не требует ключа и, кроме погоды, определяет часовой пояс по координатам
+	openMeteo := weather.NewOpenMeteo(transportOpts...)
+
+	// Провайдеры погоды опрашиваются в порядке, заданном в WEATHER_PROVIDERS
+	viper.SetDefault("WEATHER_PROVIDERS", "openweathermap,openmeteo")
+	var providers []weather.Provider
+	for _, name := range strings.Split(viper.GetString("WEATHER_PROVIDERS"), ",") {
+		switch strings.TrimSpace(name) {
+		case "openweathermap":
+			providers = append(providers, weatherClient)
+		case "openmeteo":
+			providers = append(providers, openMeteo)
+		default:
+			log.Fatalf("Unknown weather provider: %q\n", name)
+		}
+	}
+
+	// Кэшируем ответы, чтобы пользователи из одного города не расходовали квоту API повторными запросами
+	viper.SetDefault("WEATHER_CACHE_TTL", 10*time.Minute)
+	weatherCache := weather.NewCache(weather.NewFailover(providers...), viper.GetDuration("WEATHER_CACHE_TTL"))
+	// Кэш у каждого экземпляра свой
+	every(time.Hour, func() {
+		stats := weatherCache.Stats()
+		log.Printf("Weather cache: hits=%d misses=%d coalesced=%d\n", stats.Hits, stats.Misses, stats.Coalesced)
+	})
+	// Очередь отправки у каждого экземпляра своя, поэтому ее состояние пишет в лог каждый экземпляр, а не только ведущий
+	every(time.Minute, func() {
+		stats := sendQueue.Stats()
+		if stats.Interactive+stats.Scheduled > 0 || stats.Throttled > 0 {
+			log.Printf("Send queue: interactive=%d scheduled=%d sent=%d throttled=%d\n",
+				stats.Interactive, stats.Scheduled, stats.Sent, stats.Throttled)
+		}
+	})
 
 	// Создание botService с weatherClient
-	botService := botservice.New(db, bot, cronScheduler, weatherClient) // Создаем botService с cron
+	viper.SetDefault("SCHEDULE_MIN_INTERVAL", botservice.DefaultMinInterval)
+	// Несколько экземпляров с одной базой: задачи cron выполняет только ведущий, LEADER_LOCK_KEY=0 отключает выбор
+	viper.SetDefault("LEADER_LOCK_KEY", botservice.DefaultLeaderLockKey)
+	viper.SetDefault("LEADER_INTERVAL", botservice.DefaultLeaderInterval)
+	// Уведомления рассылают все экземпляры, забирая наступившие подписки из базы пачками
+	viper.SetDefault("DISPATCH_INTERVAL", botservice.DefaultDispatchInterval)
+	viper.SetDefault("DISPATCH_BATCH", botservice.DefaultDispatchBatch)
+	viper.SetDefault("DELIVERY_ATTEMPTS", botservice.DefaultDeliveryAttempts)
+	// Уведомления одной пачки группируются по месту и отправляются параллельно, DISPATCH_WORKERS=0 отключает группировку
+	viper.SetDefault("DISPATCH_WORKERS", botservice.DefaultDispatchWorkers)
+	// Что делать с уведомлениями, пропущенными, пока бот не работал: skip, latest или digest
+	viper.SetDefault("CATCH_UP_POLICY", string(botservice.DefaultCatchUpPolicy))
+	catchUp, err := botservice.ParseCatchUpPolicy(viper.GetString("CATCH_UP_POLICY"))
+	if err != nil {
+		log.Fatalf("Invalid CATCH_UP_POLICY: %v\n", err)
+	}
+	botService := botservice.New(db, sendQueue.Sender(telegram.PriorityScheduled), cronScheduler, weatherCache, weatherClient, // Создаем botService с cron
+		botservice.WithAirQuality(weatherClient),
+		botservice.WithAlerts(weatherClient),
+		botservice.WithTimezones(openMeteo),
+		botservice.WithMinInterval(viper.GetDuration("SCHEDULE_MIN_INTERVAL")),
+		botservice.WithLeaderElection(viper.GetInt64("LEADER_LOCK_KEY"), viper.GetDuration("LEADER_INTERVAL")),
+		botservice.WithDispatcher(viper.GetDuration("DISPATCH_INTERVAL"), viper.GetInt("DISPATCH_BATCH")),
+		botservice.WithDeliveryAttempts(viper.GetInt("DELIVERY_ATTEMPTS")),
+		botservice.WithDispatchWorkers(viper.GetInt("DISPATCH_WORKERS")),
+		botservice.WithCatchUp(catchUp),
+	)
+	// Уведомления рассылает каждый экземпляр, поэтому и счетчики рассылки у каждого свои
+	every(time.Hour, func() {
+		stats := botService.FanOutStats()
+		log.Printf("Dispatch fan-out: updates=%d groups=%d saved=%d\n", stats.Updates, stats.Groups, stats.Saved)
+	})
+	viper.SetDefault("WEATHER_ALERTS_INTERVAL", 10*time.Minute)
+	if err := botService.StartAlertPoller(viper.GetDuration("WEATHER_ALERTS_INTERVAL")); err != nil {
+		log.Fatalf("Failed to start alert poller: %v\n", err)
+	}
+	// Завершенные уведомления удаляются из очереди через DELIVERY_RETENTION, 0 — хранить бессрочно
+	viper.SetDefault("DELIVERY_RETENTION", botservice.DefaultDeliveryRetention)
+	if err := botService.StartDeliveryCleanup(viper.GetDuration("DELIVERY_RETENTION")); err != nil {
+		log.Fatalf("Failed to start delivery cleanup: %v\n", err)
+	}
+	viper.SetDefault("QUIET_HOURS_CHECK_INTERVAL", time.Minute)
+	if err := botService.StartQuietHoursSummaries(viper.GetDuration("QUIET_HOURS_CHECK_INTERVAL")); err != nil {
+		log.Fatalf("Failed to start quiet hours summaries: %v\n", err)
+	}
 	botService.StartScheduler()
 
-	botHandlers := handlers.NewBotHandlers(bot, botService)
+	botHandlers := handlers.NewBotHandlers(sendQueue.Sender(telegram.PriorityInteractive), botService)
 
 	// Set up bot handlers
 	bot.Handle("/start", botHandlers.HandleStart)
 	bot.Handle("/settings", botHandlers.HandleSettings)
+	bot.Handle("/forecast", botHandlers.HandleForecast)
+	bot.Handle("/air", botHandlers.HandleAir)
 	bot.Handle(tb.OnText, botHandlers.HandleText)
+	bot.Handle(tb.OnLocation, botHandlers.HandleLocation)
+	bot.Handle(&handlers.LocationButton, botHandlers.HandleLocationChoice)
+	bot.Handle("/units", botHandlers.HandleUnits)
+	bot.Handle(&handlers.UnitsButton, botHandlers.HandleUnitsChoice)
+	bot.Handle("/language", botHandlers.HandleLanguage)
+	bot.Handle(&handlers.LanguageButton, botHandlers.HandleLanguageChoice)
+	bot.Handle("/time", botHandlers.HandleTime)
+	bot.Handle("/timezone", botHandlers.HandleTimezone)
+	bot.Handle("/subscriptions", botHandlers.HandleSubscriptions)
+	bot.Handle(&handlers.SubscriptionButton, botHandlers.HandleSubscriptionChoice)
+	bot.Handle("/pause", botHandlers.HandlePause)
+	bot.Handle("/resume", botHandlers.HandleResume)
+	bot.Handle("/stop", botHandlers.HandleStop)
+	bot.Handle("/quiet", botHandlers.HandleQuiet)
+	bot.Handle(&handlers.QuietButton, botHandlers.HandleQuietChoice)
 
 	// Start the bot
 	log.Println("Бот запущен...")
 	bot.Start()
 }
+
+// every вызывает fn раз в interval в отдельной горутине. Так запускаются метрики самого процесса:
+// задачи cronScheduler выполняет только ведущий экземпляр.
+func every(interval time.Duration, fn func()) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			fn()
+		}
+	}()
+}
@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/ViolettaBykova/viot-tg-sirius/pkg/i18n"
+	botservice "github.com/ViolettaBykova/viot-tg-sirius/services/bot"
+	tb "gopkg.in/tucnak/telebot.v2"
+)
+
+// HandleAir обрабатывает команду /air
+func (h *BotHandlers) HandleAir(m *tb.Message) {
+	ctx := context.TODO()
+	lang := h.lang(ctx, m.Sender)
+	args := strings.Fields(strings.ToLower(m.Payload))
+	if len(args) == 0 {
+		h.sendAirQuality(ctx, m.Sender, lang)
+		return
+	}
+
+	switch args[0] {
+	case "вкл", "on":
+		if err := h.botService.SetShowAirQuality(ctx, m.Sender.ID, true); err != nil {
+			h.Bot.Send(m.Sender, i18n.T(lang, "error.save_setting"))
+			return
+		}
+		h.Bot.Send(m.Sender, i18n.T(lang, "air.show_on"))
+
+	case "выкл", "off":
+		if err := h.botService.SetShowAirQuality(ctx, m.Sender.ID, false); err != nil {
+			h.Bot.Send(m.Sender, i18n.T(lang, "error.save_setting"))
+			return
+		}
+		h.Bot.Send(m.Sender, i18n.T(lang, "air.show_off"))
+
+	case "порог", "alert":
+		if len(args) != 2 {
+			h.Bot.Send(m.Sender, i18n.T(lang, "air.help"))
+			return
+		}
+		level, err := strconv.Atoi(args[1])
+		if err != nil || level < 0 || level > botservice.MaxAQI {
+			h.Bot.Send(m.Sender, i18n.T(lang, "air.level_range", botservice.MaxAQI))
+			return
+		}
+		if err := h.botService.SetAQIAlertLevel(ctx, m.Sender.ID, level); err != nil {
+			h.Bot.Send(m.Sender, i18n.T(lang, "error.save_setting"))
+			return
+		}
+		if level == 0 {
+			h.Bot.Send(m.Sender, i18n.T(lang, "air.alerts_off"))
+			return
+		}
+		h.Bot.Send(m.Sender, i18n.T(lang, "air.alerts_on", i18n.T(lang, fmt.Sprintf("aqi.%d", level)), level))
+
+	default:
+		h.Bot.Send(m.Sender, i18n.T(lang, "air.help"))
+	}
+}
+
+// sendAirQuality отправляет пользователю текущее качество воздуха
+func (h *BotHandlers) sendAirQuality(ctx context.Context, user *tb.User, lang i18n.Lang) {
+	air, err := h.botService.GetAirQuality(ctx, user.ID)
+	if err != nil {
+		log.Printf("Ошибка получения качества воздуха для пользователя %d: %v", user.ID, err)
+		h.sendError(ctx, user, err)
+		return
+	}
+
+	h.Bot.Send(user, i18n.T(lang, "air.report",
+		air.City, i18n.T(lang, fmt.Sprintf("aqi.%d", air.AQI)), air.AQI, air.PM25, air.PM10, air.O3, air.NO2))
+}
@@ -4,87 +4,320 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/ViolettaBykova/viot-tg-sirius/models/scenes"
+	"github.com/ViolettaBykova/viot-tg-sirius/models/units"
+	"github.com/ViolettaBykova/viot-tg-sirius/pkg/i18n"
+	"github.com/ViolettaBykova/viot-tg-sirius/pkg/weather"
+	botservice "github.com/ViolettaBykova/viot-tg-sirius/services/bot"
 	tb "gopkg.in/tucnak/telebot.v2"
 )
 
-var validIntervals = map[string]bool{
-	"30 секунд": true,
-	"1 минута":  true,
-	"15 минут":  true,
-	"1 час":     true,
-	"6 часов":   true,
-	"12 часов":  true,
-}
+// LocationButton — кнопка выбора города из нескольких найденных геокодером
+var LocationButton = tb.InlineButton{Unique: "location"}
 
 type BotHandlers struct {
 	botService BotService
-	Bot        *tb.Bot
+	Bot        Messenger
+
+	mu      sync.Mutex
+	pending map[int64][]weather.Location // Кандидаты, предложенные пользователю на выбор
+	drafts  map[int64]subscriptionDraft  // Подписки, которые пользователи сейчас создают или меняют
 }
 
 // NewBotHandlers создаёт новый экземпляр BotHandlers с зависимостями
-func NewBotHandlers(bot *tb.Bot, botService BotService) *BotHandlers {
+func NewBotHandlers(bot Messenger, botService BotService) *BotHandlers {
 	return &BotHandlers{
 		botService: botService,
 		Bot:        bot,
+		pending:    make(map[int64][]weather.Location),
+		drafts:     make(map[int64]subscriptionDraft),
+	}
+}
+
+// lang возвращает язык пользователя. Если пользователя еще нет в базе, язык выбирается
+// по настройкам его клиента Telegram.
+func (h *BotHandlers) lang(ctx context.Context, user *tb.User) i18n.Lang {
+	lang, err := h.botService.GetUserLanguage(ctx, user.ID)
+	if err != nil || !lang.Valid() {
+		return i18n.FromTelegram(user.LanguageCode)
 	}
+	return lang
 }
 
 // HandleStart обрабатывает команду /start
 func (h *BotHandlers) HandleStart(m *tb.Message) {
 	ctx := context.TODO()
-	err := h.botService.CreateUser(ctx, m.Sender.ID, "")
+	lang := i18n.FromTelegram(m.Sender.LanguageCode)
+	err := h.botService.CreateUser(ctx, m.Sender.ID, "", lang)
 	if err != nil {
-		h.Bot.Send(m.Sender, "Ошибка при сохранении пользователя.")
+		h.Bot.Send(m.Sender, i18n.T(lang, "error.save_user"))
 		return
 	}
+	// Пользователь, которого отключили после блокировки бота, снова начинает получать уведомления
+	if err := h.botService.Reactivate(ctx, m.Sender.ID); err != nil {
+		log.Printf("Ошибка включения пользователя %d: %v", m.Sender.ID, err)
+	}
 
-	h.Bot.Send(m.Sender, "Добро пожаловать! Пожалуйста, введите город для получения прогноза погоды.")
+	// Пользователь мог уже выбрать язык раньше, CreateUser его не перезаписывает
+	lang = h.lang(ctx, m.Sender)
+	h.Bot.Send(m.Sender, i18n.T(lang, "start.welcome"), shareLocationKeyboard(lang))
 	h.botService.SetUserScene(ctx, m.Sender.ID, scenes.SceneEnterCity) // Устанавливаем сцену для ввода города
 }
 
+// shareLocationKeyboard возвращает клавиатуру с кнопкой отправки местоположения
+func shareLocationKeyboard(lang i18n.Lang) *tb.ReplyMarkup {
+	shareLocation := tb.ReplyButton{Text: i18n.T(lang, "button.share_location"), Location: true}
+	return &tb.ReplyMarkup{
+		ReplyKeyboard:       [][]tb.ReplyButton{{shareLocation}},
+		ResizeReplyKeyboard: true,
+		OneTimeKeyboard:     true,
+	}
+}
+
 // HandleText обрабатывает текстовые сообщения
 func (h *BotHandlers) HandleText(m *tb.Message) {
 	ctx := context.TODO()
+	lang := h.lang(ctx, m.Sender)
 	scene, err := h.botService.GetUserScene(ctx, m.Sender.ID)
 	if err != nil {
-		h.Bot.Send(m.Sender, "Ошибка при получении состояния пользователя.")
+		h.Bot.Send(m.Sender, i18n.T(lang, "error.get_state"))
 		return
 	}
 
 	switch scene {
-	case scenes.SceneEnterCity:
-		h.botService.SetCity(ctx, m.Sender.ID, m.Text) // Сохраняем город
-		h.Bot.Send(m.Sender, "Город сохранен. Выберите интервал обновления: 30 секунд, 1 минута, 15 минут, 1 час, 6 часов, 12 часов.")
-		h.botService.SetUserScene(ctx, m.Sender.ID, scenes.SceneSelectInterval) // Переходим на сцену выбора интервала
+	case scenes.SceneEnterCity, scenes.SceneSubscriptionCity:
+		locations, err := h.botService.FindLocations(ctx, m.Text, lang)
+		if err != nil {
+			h.Bot.Send(m.Sender, botservice.UserMessage(lang, err))
+			return
+		}
+
+		switch len(locations) {
+		case 0:
+			h.Bot.Send(m.Sender, i18n.T(lang, "city.not_found"))
+		case 1:
+			h.chooseLocation(ctx, m.Sender, lang, scene, locations[0])
+		default:
+			h.askLocation(m.Sender, lang, locations)
+		}
 
 	case scenes.SceneSelectInterval:
 
-		// Проверка, что введенный интервал допустим
-		if _, ok := validIntervals[m.Text]; !ok {
-			h.Bot.Send(m.Sender, "Некорректный интервал. Пожалуйста, выберите один из следующих: 30 секунд, 1 минута, 15 минут, 1 час, 6 часов, 12 часов.")
-			return // Прекращаем выполнение, если интервал некорректен
+		// Проверка, что введенное расписание допустимо
+		schedule, err := h.botService.ParseSchedule(m.Text)
+		if err != nil {
+			h.sendScheduleError(m.Sender, lang, err, "schedule.invalid")
+			return // Прекращаем выполнение, если расписание некорректно
 		}
 
-		// Сохраняем интервал, если он корректен
-		h.botService.SetUpdateInterval(ctx, m.Sender.ID, m.Text)
-		if err := h.botService.ScheduleWeatherUpdate(ctx, m.Sender.ID, m.Text); err != nil {
-			log.Printf("Ошибка планирования обновлений погоды для пользователя %d: %v", m.Sender.ID, err)
-			h.Bot.Send(m.Sender, "Ошибка при планировании обновлений. Попробуйте еще раз.")
-			return
+		// Сохраняем расписание, если оно корректно
+		if h.saveSchedule(ctx, m.Sender, lang, schedule) {
+			h.botService.SetUserScene(ctx, m.Sender.ID, scenes.SceneDefault)
 		}
-		h.Bot.Send(m.Sender, fmt.Sprintf("Интервал обновления установлен на %s.", m.Text))
-		h.botService.SetUserScene(ctx, m.Sender.ID, scenes.SceneDefault)
+
+	case scenes.SceneSubscriptionSchedule:
+		h.saveSubscriptionSchedule(ctx, m.Sender, lang, m.Text)
+
+	case scenes.SceneSubscriptionThresholds:
+		h.saveSubscriptionThresholds(ctx, m.Sender, lang, m.Text)
 
 	default:
-		h.Bot.Send(m.Sender, "Команда не распознана. Используйте /start для начала.")
+		h.Bot.Send(m.Sender, i18n.T(lang, "command.unknown"))
+	}
+}
+
+// HandleLocation обрабатывает присланное пользователем местоположение
+func (h *BotHandlers) HandleLocation(m *tb.Message) {
+	ctx := context.TODO()
+	lang := h.lang(ctx, m.Sender)
+	scene, err := h.botService.GetUserScene(ctx, m.Sender.ID)
+	if err != nil {
+		h.Bot.Send(m.Sender, i18n.T(lang, "error.get_state"))
+		return
+	}
+
+	location := h.botService.LocationByCoords(ctx, float64(m.Location.Lat), float64(m.Location.Lng), lang)
+	if scene == scenes.SceneEnterCity || scene == scenes.SceneSubscriptionCity {
+		h.chooseLocation(ctx, m.Sender, lang, scene, location)
+		return
+	}
+
+	// Вне сцены ввода города просто обновляем место, не меняя интервал
+	if err := h.botService.SetLocation(ctx, m.Sender.ID, location); err != nil {
+		h.Bot.Send(m.Sender, i18n.T(lang, "location.save_error"))
+		return
+	}
+	h.Bot.Send(m.Sender, i18n.T(lang, "location.updated", location.Name))
+}
+
+// HandleLocationChoice обрабатывает выбор города из списка кандидатов
+func (h *BotHandlers) HandleLocationChoice(c *tb.Callback) {
+	ctx := context.TODO()
+	lang := h.lang(ctx, c.Sender)
+	h.Bot.Respond(c)
+
+	h.mu.Lock()
+	locations := h.pending[c.Sender.ID]
+	delete(h.pending, c.Sender.ID)
+	h.mu.Unlock()
+
+	i, err := strconv.Atoi(c.Data)
+	if err != nil || i < 0 || i >= len(locations) {
+		h.Bot.Edit(c.Message, i18n.T(lang, "city.list_expired"))
+		return
+	}
+
+	h.Bot.Edit(c.Message, i18n.T(lang, "city.chosen", locationLabel(locations[i])))
+	scene, err := h.botService.GetUserScene(ctx, c.Sender.ID)
+	if err != nil {
+		h.Bot.Send(c.Sender, i18n.T(lang, "error.get_state"))
+		return
+	}
+	h.chooseLocation(ctx, c.Sender, lang, scene, locations[i])
+}
+
+// chooseLocation сохраняет выбранное место как город пользователя или как место подписки, в зависимости от сцены
+func (h *BotHandlers) chooseLocation(ctx context.Context, user *tb.User, lang i18n.Lang, scene scenes.Scene, location weather.Location) {
+	if scene == scenes.SceneSubscriptionCity {
+		h.saveSubscriptionLocation(ctx, user, lang, location)
+		return
+	}
+	h.saveLocation(ctx, user, lang, location)
+}
+
+// sendError сообщает пользователю о причине ошибки получения погоды.
+// Если ошибку можно исправить только сменой города, переводит пользователя на ввод города.
+func (h *BotHandlers) sendError(ctx context.Context, user *tb.User, err error) {
+	if botservice.NeedsCity(err) {
+		h.botService.SetUserScene(ctx, user.ID, scenes.SceneEnterCity)
+	}
+	h.Bot.Send(user, botservice.UserMessage(h.lang(ctx, user), err))
+}
+
+// saveLocation сохраняет выбранный город и переводит пользователя к выбору интервала
+func (h *BotHandlers) saveLocation(ctx context.Context, user *tb.User, lang i18n.Lang, location weather.Location) {
+	if err := h.botService.SetLocation(ctx, user.ID, location); err != nil {
+		h.Bot.Send(user, i18n.T(lang, "city.save_error"))
+		return
+	}
+	h.Bot.Send(user, i18n.T(lang, "city.saved", location.Name, intervalList(lang)), intervalKeyboard(lang))
+	h.botService.SetUserScene(ctx, user.ID, scenes.SceneSelectInterval) // Переходим на сцену выбора интервала
+}
+
+// askLocation предлагает пользователю выбрать один из найденных городов
+func (h *BotHandlers) askLocation(user *tb.User, lang i18n.Lang, locations []weather.Location) {
+	h.mu.Lock()
+	h.pending[user.ID] = locations
+	h.mu.Unlock()
+
+	keyboard := make([][]tb.InlineButton, 0, len(locations))
+	for i, location := range locations {
+		btn := *LocationButton.With(strconv.Itoa(i))
+		btn.Text = locationLabel(location)
+		keyboard = append(keyboard, []tb.InlineButton{btn})
+	}
+
+	h.Bot.Send(user, i18n.T(lang, "city.choose"), &tb.ReplyMarkup{
+		InlineKeyboard: keyboard,
+	})
+}
+
+// locationLabel возвращает подпись города: название, регион, страна
+func locationLabel(location weather.Location) string {
+	parts := []string{location.Name}
+	if location.State != "" {
+		parts = append(parts, location.State)
+	}
+	if location.Country != "" {
+		parts = append(parts, location.Country)
 	}
+	return strings.Join(parts, ", ")
 }
 
 // HandleSettings обрабатывает команду /settings
 func (h *BotHandlers) HandleSettings(m *tb.Message) {
 	ctx := context.TODO()
-	h.Bot.Send(m.Sender, "Выберите интервал обновления: 30 секунд, 1 минута, 15 минут, 1 час, 6 часов, 12 часов.")
+	lang := h.lang(ctx, m.Sender)
+	h.Bot.Send(m.Sender, i18n.T(lang, "interval.choose", intervalList(lang)), intervalKeyboard(lang))
 	h.botService.SetUserScene(ctx, m.Sender.ID, scenes.SceneSelectInterval)
 }
+
+// intervalList возвращает названия интервалов через запятую
+func intervalList(lang i18n.Lang) string {
+	names := make([]string, 0, len(botservice.Intervals))
+	for _, code := range botservice.Intervals {
+		names = append(names, i18n.T(lang, "interval."+code))
+	}
+	return strings.Join(names, ", ")
+}
+
+// intervalKeyboard возвращает клавиатуру с названиями интервалов, по три в ряд
+func intervalKeyboard(lang i18n.Lang) *tb.ReplyMarkup {
+	var keyboard [][]tb.ReplyButton
+	for i, code := range botservice.Intervals {
+		if i%3 == 0 {
+			keyboard = append(keyboard, nil)
+		}
+		row := &keyboard[len(keyboard)-1]
+		*row = append(*row, tb.ReplyButton{Text: i18n.T(lang, "interval."+code)})
+	}
+	return &tb.ReplyMarkup{
+		ReplyKeyboard:       keyboard,
+		ResizeReplyKeyboard: true,
+		OneTimeKeyboard:     true,
+	}
+}
+
+// HandleForecast обрабатывает команду /forecast
+func (h *BotHandlers) HandleForecast(m *tb.Message) {
+	ctx := context.TODO()
+	forecast, err := h.botService.GetForecast(ctx, m.Sender.ID)
+	if err != nil {
+		log.Printf("Ошибка получения прогноза для пользователя %d: %v", m.Sender.ID, err)
+		h.sendError(ctx, m.Sender, err)
+		return
+	}
+	u, err := h.botService.GetUserUnits(ctx, m.Sender.ID)
+	if err != nil {
+		u = units.Metric
+	}
+
+	h.Bot.Send(m.Sender, formatForecast(forecast, u, h.lang(ctx, m.Sender)))
+}
+
+// forecastDays — сколько дней показывать в ответе на /forecast
+const forecastDays = 5
+
+// formatForecast формирует текст прогноза по дням на языке lang
+func formatForecast(forecast *weather.Forecast, u units.Units, lang i18n.Lang) string {
+	var sb strings.Builder
+	sb.WriteString(i18n.T(lang, "forecast.title", forecast.City) + "\n")
+	for i, day := range forecast.Days {
+		if i >= forecastDays {
+			break
+		}
+		minTemp := fmt.Sprintf("%.0f%s", u.Temp(day.TempMin), u.TempUnit())
+		maxTemp := fmt.Sprintf("%.0f%s", u.Temp(day.TempMax), u.TempUnit())
+		sb.WriteString("\n" + i18n.T(lang, "forecast.day", dayLabel(day.Date, lang), day.Description, minTemp, maxTemp, day.PrecipProb*100) + "\n")
+	}
+	return sb.String()
+}
+
+// dayLabel возвращает подпись дня: сегодня, завтра или день недели с датой
+func dayLabel(date time.Time, lang i18n.Lang) string {
+	now := time.Now().In(date.Location())
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, date.Location())
+	switch {
+	case date.Equal(today):
+		return i18n.T(lang, "day.today")
+	case date.Equal(today.AddDate(0, 0, 1)):
+		return i18n.T(lang, "day.tomorrow")
+	default:
+		return fmt.Sprintf("%s, %s", i18n.T(lang, fmt.Sprintf("weekday.%d", date.Weekday())), date.Format("02.01"))
+	}
+}
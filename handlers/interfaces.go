@@ -2,19 +2,65 @@ package handlers
 
 import (
 	"context"
+	"time"
 
+	"github.com/ViolettaBykova/viot-tg-sirius/models"
 	"github.com/ViolettaBykova/viot-tg-sirius/models/scenes"
+	"github.com/ViolettaBykova/viot-tg-sirius/models/units"
+	"github.com/ViolettaBykova/viot-tg-sirius/pkg/i18n"
+	"github.com/ViolettaBykova/viot-tg-sirius/pkg/weather"
+	tb "gopkg.in/tucnak/telebot.v2"
 )
 
 type (
 	BotService interface {
-		CreateUser(ctx context.Context, telegramID int64, city string) error
+		CreateUser(ctx context.Context, telegramID int64, city string, lang i18n.Lang) error
+		Reactivate(ctx context.Context, telegramID int64) error
 		GetUserCity(ctx context.Context, telegramID int64) (string, error)
 		GetUserScene(ctx context.Context, telegramID int64) (scenes.Scene, error)
 		SetUserScene(ctx context.Context, telegramID int64, scene scenes.Scene) error
 		SetCity(ctx context.Context, telegramID int64, city string) error
-		SetUpdateInterval(ctx context.Context, telegramID int64, interval string) error
+		SetLocation(ctx context.Context, telegramID int64, location weather.Location) error
+		FindLocations(ctx context.Context, query string, lang i18n.Lang) ([]weather.Location, error)
+		LocationByCoords(ctx context.Context, lat, lon float64, lang i18n.Lang) weather.Location
+		GetUserUnits(ctx context.Context, telegramID int64) (units.Units, error)
+		SetUnits(ctx context.Context, telegramID int64, u units.Units) error
+		GetUserLanguage(ctx context.Context, telegramID int64) (i18n.Lang, error)
+		SetLanguage(ctx context.Context, telegramID int64, lang i18n.Lang) error
+		GetUserTimezone(ctx context.Context, telegramID int64) (string, error)
+		SetTimezone(ctx context.Context, telegramID int64, timezone string) error
+		ParseSchedule(text string) (string, error)
+		SetSchedule(ctx context.Context, telegramID int64, schedule string) (int64, string, error)
+		NextRuns(ctx context.Context, telegramID, subscriptionID int64, n int) ([]time.Time, error)
+		MinInterval() time.Duration
+		Pause(ctx context.Context, telegramID int64, text string) (*time.Time, error)
+		Resume(ctx context.Context, telegramID int64) error
+		Stop(ctx context.Context, telegramID int64) error
+		GetQuietHours(ctx context.Context, telegramID int64) (from, to string, mode models.QuietMode, err error)
+		SetQuietHours(ctx context.Context, telegramID int64, text string) (string, string, error)
+		SetQuietMode(ctx context.Context, telegramID int64, mode models.QuietMode) error
 
-		ScheduleWeatherUpdate(ctx context.Context, telegramID int64, interval string) error
+		Subscriptions(ctx context.Context, telegramID int64) ([]models.Subscription, error)
+		GetSubscription(ctx context.Context, telegramID, subscriptionID int64) (*models.Subscription, error)
+		AddSubscription(ctx context.Context, telegramID int64, location weather.Location, schedule string) (*models.Subscription, string, error)
+		SetSubscriptionSchedule(ctx context.Context, telegramID, subscriptionID int64, schedule string) (string, error)
+		SetSubscriptionLocation(ctx context.Context, telegramID, subscriptionID int64, location weather.Location) error
+		SetSubscriptionFormat(ctx context.Context, telegramID, subscriptionID int64, format models.Format) error
+		SetSubscriptionEnabled(ctx context.Context, telegramID, subscriptionID int64, enabled bool) error
+		SetSubscriptionOnlyOnChange(ctx context.Context, telegramID, subscriptionID int64, on bool) error
+		SetSubscriptionThresholds(ctx context.Context, telegramID, subscriptionID int64, text string, u units.Units) error
+		RemoveSubscription(ctx context.Context, telegramID, subscriptionID int64) error
+
+		GetForecast(ctx context.Context, telegramID int64) (*weather.Forecast, error)
+		GetAirQuality(ctx context.Context, telegramID int64) (*weather.AirQuality, error)
+		SetShowAirQuality(ctx context.Context, telegramID int64, show bool) error
+		SetAQIAlertLevel(ctx context.Context, telegramID int64, level int) error
+	}
+
+	// Messenger отправляет ответы пользователям, например через очередь с учетом ограничений Telegram
+	Messenger interface {
+		Send(to tb.Recipient, what interface{}, options ...interface{}) (*tb.Message, error)
+		Edit(msg tb.Editable, what interface{}, options ...interface{}) (*tb.Message, error)
+		Respond(c *tb.Callback, resp ...*tb.CallbackResponse) error
 	}
 )
@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"context"
+
+	"github.com/ViolettaBykova/viot-tg-sirius/pkg/i18n"
+	tb "gopkg.in/tucnak/telebot.v2"
+)
+
+// LanguageButton — кнопка выбора языка сообщений
+var LanguageButton = tb.InlineButton{Unique: "language"}
+
+// HandleLanguage обрабатывает команду /language
+func (h *BotHandlers) HandleLanguage(m *tb.Message) {
+	lang := h.lang(context.TODO(), m.Sender)
+	keyboard := make([][]tb.InlineButton, 0, len(i18n.Supported))
+	for _, l := range i18n.Supported {
+		btn := *LanguageButton.With(string(l))
+		btn.Text = l.Name()
+		keyboard = append(keyboard, []tb.InlineButton{btn})
+	}
+
+	h.Bot.Send(m.Sender, i18n.T(lang, "language.choose"), &tb.ReplyMarkup{
+		InlineKeyboard: keyboard,
+	})
+}
+
+// HandleLanguageChoice обрабатывает выбор языка сообщений
+func (h *BotHandlers) HandleLanguageChoice(c *tb.Callback) {
+	ctx := context.TODO()
+	h.Bot.Respond(c)
+
+	lang := i18n.Lang(c.Data)
+	if err := h.botService.SetLanguage(ctx, c.Sender.ID, lang); err != nil {
+		h.Bot.Edit(c.Message, i18n.T(h.lang(ctx, c.Sender), "error.save_setting"))
+		return
+	}
+	h.Bot.Edit(c.Message, i18n.T(lang, "language.set", lang.Name()))
+}
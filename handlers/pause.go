@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/ViolettaBykova/viot-tg-sirius/pkg/i18n"
+	botservice "github.com/ViolettaBykova/viot-tg-sirius/services/bot"
+	tb "gopkg.in/tucnak/telebot.v2"
+)
+
+// HandlePause обрабатывает команду /pause: без срока или со сроком, например /pause до понедельника
+func (h *BotHandlers) HandlePause(m *tb.Message) {
+	ctx := context.TODO()
+	lang := h.lang(ctx, m.Sender)
+	until, err := h.botService.Pause(ctx, m.Sender.ID, m.Payload)
+	switch {
+	case errors.Is(err, botservice.ErrInvalidPause):
+		h.Bot.Send(m.Sender, i18n.T(lang, "pause.invalid"))
+	case err != nil:
+		log.Printf("Ошибка приостановки уведомлений для пользователя %d: %v", m.Sender.ID, err)
+		h.Bot.Send(m.Sender, i18n.T(lang, "error.save_setting"))
+	case until == nil:
+		h.Bot.Send(m.Sender, i18n.T(lang, "pause.set"))
+	default:
+		when := fmt.Sprintf("%s, %s", i18n.T(lang, fmt.Sprintf("weekday.%d", until.Weekday())), until.Format("02.01 15:04"))
+		h.Bot.Send(m.Sender, i18n.T(lang, "pause.set_until", when))
+	}
+}
+
+// HandleResume обрабатывает команду /resume
+func (h *BotHandlers) HandleResume(m *tb.Message) {
+	ctx := context.TODO()
+	lang := h.lang(ctx, m.Sender)
+	if err := h.botService.Resume(ctx, m.Sender.ID); err != nil {
+		log.Printf("Ошибка возобновления уведомлений для пользователя %d: %v", m.Sender.ID, err)
+		h.Bot.Send(m.Sender, i18n.T(lang, "error.save_setting"))
+		return
+	}
+	h.Bot.Send(m.Sender, i18n.T(lang, "resume.done"))
+}
+
+// HandleStop обрабатывает команду /stop
+func (h *BotHandlers) HandleStop(m *tb.Message) {
+	ctx := context.TODO()
+	lang := h.lang(ctx, m.Sender)
+	if err := h.botService.Stop(ctx, m.Sender.ID); err != nil {
+		log.Printf("Ошибка остановки уведомлений для пользователя %d: %v", m.Sender.ID, err)
+		h.Bot.Send(m.Sender, i18n.T(lang, "error.save_setting"))
+		return
+	}
+	h.Bot.Send(m.Sender, i18n.T(lang, "stop.done"))
+}
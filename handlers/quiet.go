@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+
+	"github.com/ViolettaBykova/viot-tg-sirius/models"
+	"github.com/ViolettaBykova/viot-tg-sirius/pkg/i18n"
+	botservice "github.com/ViolettaBykova/viot-tg-sirius/services/bot"
+	tb "gopkg.in/tucnak/telebot.v2"
+)
+
+// QuietButton — кнопка выбора, что делать с обновлениями в тихие часы
+var QuietButton = tb.InlineButton{Unique: "quiet"}
+
+var quietModes = []models.QuietMode{models.QuietSkip, models.QuietSilent}
+
+// HandleQuiet обрабатывает команду /quiet: без аргументов показывает тихие часы, с аргументом — задает их
+func (h *BotHandlers) HandleQuiet(m *tb.Message) {
+	ctx := context.TODO()
+	lang := h.lang(ctx, m.Sender)
+	if strings.TrimSpace(m.Payload) == "" {
+		from, to, mode, err := h.botService.GetQuietHours(ctx, m.Sender.ID)
+		if err != nil {
+			h.Bot.Send(m.Sender, i18n.T(lang, "error.get_state"))
+			return
+		}
+		if from == "" {
+			h.Bot.Send(m.Sender, i18n.T(lang, "quiet.off"))
+			return
+		}
+		h.Bot.Send(m.Sender, i18n.T(lang, "quiet.current", from, to, i18n.T(lang, "quiet.mode."+string(mode))), quietKeyboard(lang))
+		return
+	}
+
+	from, to, err := h.botService.SetQuietHours(ctx, m.Sender.ID, m.Payload)
+	switch {
+	case errors.Is(err, botservice.ErrInvalidQuietHours):
+		h.Bot.Send(m.Sender, i18n.T(lang, "quiet.invalid"))
+	case err != nil:
+		log.Printf("Ошибка сохранения тихих часов для пользователя %d: %v", m.Sender.ID, err)
+		h.Bot.Send(m.Sender, i18n.T(lang, "error.save_setting"))
+	case from == "":
+		h.Bot.Send(m.Sender, i18n.T(lang, "quiet.disabled"))
+	default:
+		h.Bot.Send(m.Sender, i18n.T(lang, "quiet.set", from, to), quietKeyboard(lang))
+	}
+}
+
+// HandleQuietChoice обрабатывает выбор, что делать с обновлениями в тихие часы
+func (h *BotHandlers) HandleQuietChoice(c *tb.Callback) {
+	ctx := context.TODO()
+	lang := h.lang(ctx, c.Sender)
+	h.Bot.Respond(c)
+
+	mode := models.QuietMode(c.Data)
+	if err := h.botService.SetQuietMode(ctx, c.Sender.ID, mode); err != nil {
+		h.Bot.Edit(c.Message, i18n.T(lang, "error.save_setting"))
+		return
+	}
+	h.Bot.Edit(c.Message, i18n.T(lang, "quiet.mode_set", i18n.T(lang, "quiet.mode."+string(mode))))
+}
+
+// quietKeyboard возвращает кнопки выбора, что делать с обновлениями в тихие часы
+func quietKeyboard(lang i18n.Lang) *tb.ReplyMarkup {
+	row := make([]tb.InlineButton, 0, len(quietModes))
+	for _, mode := range quietModes {
+		btn := *QuietButton.With(string(mode))
+		btn.Text = i18n.T(lang, "button.quiet."+string(mode))
+		row = append(row, btn)
+	}
+	return &tb.ReplyMarkup{InlineKeyboard: [][]tb.InlineButton{row}}
+}
@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/ViolettaBykova/viot-tg-sirius/pkg/i18n"
+	botservice "github.com/ViolettaBykova/viot-tg-sirius/services/bot"
+	tb "gopkg.in/tucnak/telebot.v2"
+)
+
+// previewRuns — сколько ближайших уведомлений показывать после сохранения расписания
+const previewRuns = 5
+
+// HandleTime обрабатывает команду /time: уведомления в заданное местное время
+func (h *BotHandlers) HandleTime(m *tb.Message) {
+	ctx := context.TODO()
+	lang := h.lang(ctx, m.Sender)
+	if strings.TrimSpace(m.Payload) == "" {
+		h.Bot.Send(m.Sender, i18n.T(lang, "time.help"))
+		return
+	}
+
+	schedule, err := h.botService.ParseSchedule("at " + m.Payload)
+	if err != nil {
+		h.sendScheduleError(m.Sender, lang, err, "time.invalid")
+		return
+	}
+	h.saveSchedule(ctx, m.Sender, lang, schedule)
+}
+
+// saveSchedule сохраняет расписание подписки на город пользователя и показывает ближайшие уведомления по нему
+func (h *BotHandlers) saveSchedule(ctx context.Context, user *tb.User, lang i18n.Lang, schedule string) bool {
+	subscriptionID, timezone, err := h.botService.SetSchedule(ctx, user.ID, schedule)
+	if err != nil {
+		log.Printf("Ошибка планирования обновлений погоды для пользователя %d: %v", user.ID, err)
+		if botservice.NeedsCity(err) {
+			h.sendError(ctx, user, err)
+			return false
+		}
+		h.Bot.Send(user, i18n.T(lang, "interval.schedule_error"))
+		return false
+	}
+	h.sendRuns(ctx, user, lang, subscriptionID, timezone)
+	return true
+}
+
+// sendRuns подтверждает сохранение расписания подписки и показывает ближайшие уведомления по нему
+func (h *BotHandlers) sendRuns(ctx context.Context, user *tb.User, lang i18n.Lang, subscriptionID int64, timezone string) {
+	if timezone == "" {
+		timezone = "UTC"
+	}
+
+	done := &tb.ReplyMarkup{ReplyKeyboardRemove: true}
+	runs, err := h.botService.NextRuns(ctx, user.ID, subscriptionID, previewRuns)
+	if err != nil || len(runs) == 0 {
+		h.Bot.Send(user, i18n.T(lang, "schedule.set_no_runs", timezone), done)
+		return
+	}
+
+	lines := make([]string, 0, len(runs))
+	for _, run := range runs {
+		lines = append(lines, fmt.Sprintf("• %s, %s",
+			i18n.T(lang, fmt.Sprintf("weekday.%d", run.Weekday())), run.Format("02.01 15:04")))
+	}
+	h.Bot.Send(user, i18n.T(lang, "schedule.set", timezone, strings.Join(lines, "\n")), done)
+}
+
+// sendScheduleError объясняет, почему расписание не подошло. invalidKey — сообщение для нераспознанного ввода.
+func (h *BotHandlers) sendScheduleError(user *tb.User, lang i18n.Lang, err error, invalidKey string) {
+	switch {
+	case errors.Is(err, botservice.ErrScheduleTooFrequent):
+		h.Bot.Send(user, i18n.T(lang, "schedule.too_frequent", h.botService.MinInterval()))
+	case errors.Is(err, botservice.ErrTooManyTimes):
+		h.Bot.Send(user, i18n.T(lang, "time.too_many", botservice.MaxDailyTimes))
+	case errors.Is(err, botservice.ErrInvalidTimeOfDay):
+		h.Bot.Send(user, i18n.T(lang, "time.invalid"))
+	default:
+		h.Bot.Send(user, i18n.T(lang, invalidKey))
+	}
+}
+
+// HandleTimezone обрабатывает команду /timezone
+func (h *BotHandlers) HandleTimezone(m *tb.Message) {
+	ctx := context.TODO()
+	lang := h.lang(ctx, m.Sender)
+	timezone := strings.TrimSpace(m.Payload)
+	if timezone == "" {
+		current, err := h.botService.GetUserTimezone(ctx, m.Sender.ID)
+		if err != nil {
+			h.Bot.Send(m.Sender, i18n.T(lang, "error.get_state"))
+			return
+		}
+		if current == "" {
+			h.Bot.Send(m.Sender, i18n.T(lang, "timezone.not_set"))
+			return
+		}
+		h.Bot.Send(m.Sender, i18n.T(lang, "timezone.current", current))
+		return
+	}
+
+	err := h.botService.SetTimezone(ctx, m.Sender.ID, timezone)
+	switch {
+	case errors.Is(err, botservice.ErrInvalidTimezone):
+		h.Bot.Send(m.Sender, i18n.T(lang, "timezone.invalid"))
+	case err != nil:
+		log.Printf("Ошибка смены часового пояса для пользователя %d: %v", m.Sender.ID, err)
+		h.Bot.Send(m.Sender, i18n.T(lang, "error.save_setting"))
+	default:
+		h.Bot.Send(m.Sender, i18n.T(lang, "timezone.set", timezone))
+	}
+}
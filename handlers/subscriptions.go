@@ -0,0 +1,389 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/ViolettaBykova/viot-tg-sirius/models"
+	"github.com/ViolettaBykova/viot-tg-sirius/models/scenes"
+	"github.com/ViolettaBykova/viot-tg-sirius/pkg/i18n"
+	"github.com/ViolettaBykova/viot-tg-sirius/pkg/weather"
+	botservice "github.com/ViolettaBykova/viot-tg-sirius/services/bot"
+	tb "gopkg.in/tucnak/telebot.v2"
+)
+
+// SubscriptionButton — кнопка управления подписками. Данные кнопки — "действие:ID подписки".
+var SubscriptionButton = tb.InlineButton{Unique: "subscription"}
+
+// Действия кнопок управления подписками
+const (
+	subscriptionList       = "list"
+	subscriptionAdd        = "add"
+	subscriptionEdit       = "edit"
+	subscriptionSchedule   = "schedule"
+	subscriptionLocation   = "location"
+	subscriptionFormat     = "format"
+	subscriptionToggle     = "toggle"
+	subscriptionChanges    = "changes"
+	subscriptionThresholds = "thresholds"
+	subscriptionRemove     = "remove"
+)
+
+// subscriptionDraft — подписка, которую пользователь сейчас создает или меняет
+type subscriptionDraft struct {
+	id       int64             // ID подписки, 0 для новой
+	location *weather.Location // Место новой подписки, выбранное до ввода расписания
+}
+
+// HandleSubscriptions обрабатывает команду /subscriptions
+func (h *BotHandlers) HandleSubscriptions(m *tb.Message) {
+	ctx := context.TODO()
+	lang := h.lang(ctx, m.Sender)
+	text, markup, err := h.subscriptionsView(ctx, m.Sender.ID, lang)
+	if err != nil {
+		h.Bot.Send(m.Sender, i18n.T(lang, "error.get_state"))
+		return
+	}
+	h.Bot.Send(m.Sender, text, markup)
+}
+
+// HandleSubscriptionChoice обрабатывает нажатие кнопок управления подписками
+func (h *BotHandlers) HandleSubscriptionChoice(c *tb.Callback) {
+	ctx := context.TODO()
+	lang := h.lang(ctx, c.Sender)
+	h.Bot.Respond(c)
+
+	action, data, _ := strings.Cut(c.Data, ":")
+	id, _ := strconv.ParseInt(data, 10, 64)
+
+	switch action {
+	case subscriptionList:
+		h.editSubscriptions(ctx, c, lang)
+
+	case subscriptionAdd:
+		h.startDraft(c.Sender.ID, 0)
+		h.Bot.Send(c.Sender, i18n.T(lang, "subscription.enter_city"), shareLocationKeyboard(lang))
+		h.botService.SetUserScene(ctx, c.Sender.ID, scenes.SceneSubscriptionCity)
+
+	case subscriptionEdit:
+		h.editSubscription(ctx, c, lang, id)
+
+	case subscriptionSchedule:
+		if _, err := h.botService.GetSubscription(ctx, c.Sender.ID, id); err != nil {
+			h.sendSubscriptionError(c.Sender, lang, err)
+			return
+		}
+		h.startDraft(c.Sender.ID, id)
+		h.Bot.Send(c.Sender, i18n.T(lang, "subscription.enter_schedule", intervalList(lang)), intervalKeyboard(lang))
+		h.botService.SetUserScene(ctx, c.Sender.ID, scenes.SceneSubscriptionSchedule)
+
+	case subscriptionLocation:
+		if _, err := h.botService.GetSubscription(ctx, c.Sender.ID, id); err != nil {
+			h.sendSubscriptionError(c.Sender, lang, err)
+			return
+		}
+		h.startDraft(c.Sender.ID, id)
+		h.Bot.Send(c.Sender, i18n.T(lang, "subscription.change_city"), shareLocationKeyboard(lang))
+		h.botService.SetUserScene(ctx, c.Sender.ID, scenes.SceneSubscriptionCity)
+
+	case subscriptionFormat:
+		sub, err := h.botService.GetSubscription(ctx, c.Sender.ID, id)
+		if err != nil {
+			h.sendSubscriptionError(c.Sender, lang, err)
+			return
+		}
+		format := models.FormatShort
+		if sub.Format == models.FormatShort {
+			format = models.FormatFull
+		}
+		if err := h.botService.SetSubscriptionFormat(ctx, c.Sender.ID, id, format); err != nil {
+			h.sendSubscriptionError(c.Sender, lang, err)
+			return
+		}
+		h.editSubscription(ctx, c, lang, id)
+
+	case subscriptionToggle:
+		sub, err := h.botService.GetSubscription(ctx, c.Sender.ID, id)
+		if err != nil {
+			h.sendSubscriptionError(c.Sender, lang, err)
+			return
+		}
+		if err := h.botService.SetSubscriptionEnabled(ctx, c.Sender.ID, id, !sub.Enabled); err != nil {
+			h.sendSubscriptionError(c.Sender, lang, err)
+			return
+		}
+		h.editSubscription(ctx, c, lang, id)
+
+	case subscriptionChanges:
+		sub, err := h.botService.GetSubscription(ctx, c.Sender.ID, id)
+		if err != nil {
+			h.sendSubscriptionError(c.Sender, lang, err)
+			return
+		}
+		if err := h.botService.SetSubscriptionOnlyOnChange(ctx, c.Sender.ID, id, !sub.OnlyOnChange); err != nil {
+			h.sendSubscriptionError(c.Sender, lang, err)
+			return
+		}
+		h.editSubscription(ctx, c, lang, id)
+
+	case subscriptionThresholds:
+		sub, err := h.botService.GetSubscription(ctx, c.Sender.ID, id)
+		if err != nil {
+			h.sendSubscriptionError(c.Sender, lang, err)
+			return
+		}
+		u := sub.User.Units
+		h.startDraft(c.Sender.ID, id)
+		h.Bot.Send(c.Sender, i18n.T(lang, "subscription.enter_thresholds", u.TempUnit(), i18n.T(lang, "unit."+u.SpeedUnit())))
+		h.botService.SetUserScene(ctx, c.Sender.ID, scenes.SceneSubscriptionThresholds)
+
+	case subscriptionRemove:
+		if err := h.botService.RemoveSubscription(ctx, c.Sender.ID, id); err != nil {
+			h.sendSubscriptionError(c.Sender, lang, err)
+			return
+		}
+		h.Bot.Send(c.Sender, i18n.T(lang, "subscription.removed"))
+		h.editSubscriptions(ctx, c, lang)
+	}
+}
+
+// editSubscriptions заменяет сообщение с кнопкой списком подписок
+func (h *BotHandlers) editSubscriptions(ctx context.Context, c *tb.Callback, lang i18n.Lang) {
+	text, markup, err := h.subscriptionsView(ctx, c.Sender.ID, lang)
+	if err != nil {
+		h.Bot.Send(c.Sender, i18n.T(lang, "error.get_state"))
+		return
+	}
+	h.Bot.Edit(c.Message, text, markup)
+}
+
+// editSubscription заменяет сообщение с кнопкой настройками подписки
+func (h *BotHandlers) editSubscription(ctx context.Context, c *tb.Callback, lang i18n.Lang, id int64) {
+	sub, err := h.botService.GetSubscription(ctx, c.Sender.ID, id)
+	if err != nil {
+		h.sendSubscriptionError(c.Sender, lang, err)
+		return
+	}
+	text, markup := subscriptionView(sub, lang)
+	h.Bot.Edit(c.Message, text, markup)
+}
+
+// subscriptionsView возвращает список подписок пользователя с кнопками для их изменения
+func (h *BotHandlers) subscriptionsView(ctx context.Context, telegramID int64, lang i18n.Lang) (string, *tb.ReplyMarkup, error) {
+	subs, err := h.botService.Subscriptions(ctx, telegramID)
+	if err != nil {
+		return "", nil, err
+	}
+
+	addButton := subscriptionButton(i18n.T(lang, "button.subscription_add"), subscriptionAdd, 0)
+	if len(subs) == 0 {
+		return i18n.T(lang, "subscriptions.empty"), &tb.ReplyMarkup{
+			InlineKeyboard: [][]tb.InlineButton{{addButton}},
+		}, nil
+	}
+
+	lines := []string{i18n.T(lang, "subscriptions.title")}
+	keyboard := make([][]tb.InlineButton, 0, len(subs)+1)
+	for i, sub := range subs {
+		disabled := ""
+		if !sub.Enabled {
+			disabled = i18n.T(lang, "subscriptions.disabled")
+		}
+		lines = append(lines, i18n.T(lang, "subscriptions.item", i+1, sub.City, botservice.ScheduleLabel(lang, sub.Schedule), disabled))
+		keyboard = append(keyboard, []tb.InlineButton{
+			subscriptionButton(fmt.Sprintf("%d. %s", i+1, sub.City), subscriptionEdit, sub.ID),
+		})
+	}
+	keyboard = append(keyboard, []tb.InlineButton{addButton})
+	return strings.Join(lines, "\n"), &tb.ReplyMarkup{InlineKeyboard: keyboard}, nil
+}
+
+// subscriptionView возвращает описание подписки с кнопками для ее изменения
+func subscriptionView(sub *models.Subscription, lang i18n.Lang) (string, *tb.ReplyMarkup) {
+	state, toggle := i18n.T(lang, "subscription.enabled"), i18n.T(lang, "button.disable")
+	if !sub.Enabled {
+		state, toggle = i18n.T(lang, "subscription.disabled"), i18n.T(lang, "button.enable")
+	}
+	format := i18n.T(lang, "format."+string(sub.Format))
+
+	text := i18n.T(lang, "subscription.details", sub.City, botservice.ScheduleLabel(lang, sub.Schedule), format, state)
+	changes := []tb.InlineButton{subscriptionButton(i18n.T(lang, "button.only_on_change"), subscriptionChanges, sub.ID)}
+	if sub.OnlyOnChange {
+		text += "\n" + i18n.T(lang, "subscription.only_on_change",
+			thresholdLabel(lang, sub.TempThreshold, sub.User.Units.Temp(1)-sub.User.Units.Temp(0), sub.User.Units.TempUnit()),
+			thresholdLabel(lang, sub.WindThreshold, sub.User.Units.Speed(1), " "+i18n.T(lang, "unit."+sub.User.Units.SpeedUnit())))
+		changes = []tb.InlineButton{
+			subscriptionButton(i18n.T(lang, "button.always"), subscriptionChanges, sub.ID),
+			subscriptionButton(i18n.T(lang, "button.thresholds"), subscriptionThresholds, sub.ID),
+		}
+	}
+	return text, &tb.ReplyMarkup{
+		InlineKeyboard: [][]tb.InlineButton{
+			{
+				subscriptionButton(i18n.T(lang, "button.schedule"), subscriptionSchedule, sub.ID),
+				subscriptionButton(i18n.T(lang, "button.location"), subscriptionLocation, sub.ID),
+			},
+			{
+				subscriptionButton(i18n.T(lang, "button.format", format), subscriptionFormat, sub.ID),
+				subscriptionButton(toggle, subscriptionToggle, sub.ID),
+			},
+			changes,
+			{
+				subscriptionButton(i18n.T(lang, "button.remove"), subscriptionRemove, sub.ID),
+				subscriptionButton(i18n.T(lang, "button.back"), subscriptionList, 0),
+			},
+		},
+	}
+}
+
+// thresholdLabel возвращает порог изменения в единицах пользователя: scale — цена одной единицы хранения,
+// unit — обозначение единицы. Нулевой порог не учитывается.
+func thresholdLabel(lang i18n.Lang, threshold, scale float64, unit string) string {
+	if threshold == 0 {
+		return i18n.T(lang, "subscription.threshold_off")
+	}
+	return strconv.FormatFloat(threshold*scale, 'f', -1, 64) + unit
+}
+
+// subscriptionButton возвращает кнопку действия action над подпиской id
+func subscriptionButton(text, action string, id int64) tb.InlineButton {
+	btn := *SubscriptionButton.With(fmt.Sprintf("%s:%d", action, id))
+	btn.Text = text
+	return btn
+}
+
+// saveSubscriptionLocation сохраняет место изменяемой подписки. Для новой подписки место запоминается
+// до ввода расписания.
+func (h *BotHandlers) saveSubscriptionLocation(ctx context.Context, user *tb.User, lang i18n.Lang, location weather.Location) {
+	draft, ok := h.draft(user.ID)
+	if !ok {
+		h.Bot.Send(user, i18n.T(lang, "subscription.not_found"))
+		h.botService.SetUserScene(ctx, user.ID, scenes.SceneDefault)
+		return
+	}
+
+	if draft.id == 0 {
+		h.mu.Lock()
+		h.drafts[user.ID] = subscriptionDraft{location: &location}
+		h.mu.Unlock()
+
+		h.Bot.Send(user, i18n.T(lang, "subscription.city_chosen", location.Name, intervalList(lang)), intervalKeyboard(lang))
+		h.botService.SetUserScene(ctx, user.ID, scenes.SceneSubscriptionSchedule)
+		return
+	}
+
+	if err := h.botService.SetSubscriptionLocation(ctx, user.ID, draft.id, location); err != nil {
+		log.Printf("Ошибка изменения места подписки %d: %v", draft.id, err)
+		h.sendSubscriptionError(user, lang, err)
+		return
+	}
+	h.finishDraft(user.ID)
+	h.Bot.Send(user, i18n.T(lang, "subscription.location_set", location.Name), &tb.ReplyMarkup{ReplyKeyboardRemove: true})
+	h.botService.SetUserScene(ctx, user.ID, scenes.SceneDefault)
+}
+
+// saveSubscriptionSchedule сохраняет расписание изменяемой подписки или создает новую подписку
+func (h *BotHandlers) saveSubscriptionSchedule(ctx context.Context, user *tb.User, lang i18n.Lang, text string) {
+	schedule, err := h.botService.ParseSchedule(text)
+	if err != nil {
+		h.sendScheduleError(user, lang, err, "schedule.invalid")
+		return
+	}
+
+	draft, ok := h.draft(user.ID)
+	if !ok || (draft.id == 0 && draft.location == nil) {
+		h.Bot.Send(user, i18n.T(lang, "subscription.not_found"))
+		h.botService.SetUserScene(ctx, user.ID, scenes.SceneDefault)
+		return
+	}
+
+	id, timezone := draft.id, ""
+	if id == 0 {
+		var sub *models.Subscription
+		sub, timezone, err = h.botService.AddSubscription(ctx, user.ID, *draft.location, schedule)
+		if sub != nil {
+			id = sub.ID
+		}
+	} else {
+		timezone, err = h.botService.SetSubscriptionSchedule(ctx, user.ID, id, schedule)
+	}
+	if err != nil {
+		log.Printf("Ошибка сохранения расписания подписки пользователя %d: %v", user.ID, err)
+		if errors.Is(err, botservice.ErrSubscriptionNotFound) {
+			h.sendSubscriptionError(user, lang, err)
+			return
+		}
+		if errors.Is(err, botservice.ErrCityNotSet) {
+			h.Bot.Send(user, i18n.T(lang, "schedule.no_timezone"))
+			return
+		}
+		h.Bot.Send(user, i18n.T(lang, "interval.schedule_error"))
+		return
+	}
+
+	h.finishDraft(user.ID)
+	h.sendRuns(ctx, user, lang, id, timezone)
+	h.botService.SetUserScene(ctx, user.ID, scenes.SceneDefault)
+}
+
+// saveSubscriptionThresholds сохраняет пороги изменения погоды изменяемой подписки
+func (h *BotHandlers) saveSubscriptionThresholds(ctx context.Context, user *tb.User, lang i18n.Lang, text string) {
+	draft, ok := h.draft(user.ID)
+	if !ok || draft.id == 0 {
+		h.Bot.Send(user, i18n.T(lang, "subscription.not_found"))
+		h.botService.SetUserScene(ctx, user.ID, scenes.SceneDefault)
+		return
+	}
+	u, err := h.botService.GetUserUnits(ctx, user.ID)
+	if err != nil {
+		h.Bot.Send(user, i18n.T(lang, "error.get_state"))
+		return
+	}
+
+	err = h.botService.SetSubscriptionThresholds(ctx, user.ID, draft.id, text, u)
+	if errors.Is(err, botservice.ErrInvalidThresholds) {
+		h.Bot.Send(user, i18n.T(lang, "subscription.thresholds_invalid"))
+		return
+	}
+	if err != nil {
+		h.sendSubscriptionError(user, lang, err)
+		return
+	}
+	h.finishDraft(user.ID)
+	h.Bot.Send(user, i18n.T(lang, "subscription.thresholds_set"))
+	h.botService.SetUserScene(ctx, user.ID, scenes.SceneDefault)
+}
+
+// sendSubscriptionError сообщает об ошибке при работе с подпиской
+func (h *BotHandlers) sendSubscriptionError(user *tb.User, lang i18n.Lang, err error) {
+	if errors.Is(err, botservice.ErrSubscriptionNotFound) {
+		h.finishDraft(user.ID)
+		h.Bot.Send(user, i18n.T(lang, "subscription.not_found"))
+		return
+	}
+	log.Printf("Ошибка изменения подписки пользователя %d: %v", user.ID, err)
+	h.Bot.Send(user, i18n.T(lang, "error.save_setting"))
+}
+
+func (h *BotHandlers) startDraft(telegramID, subscriptionID int64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.drafts[telegramID] = subscriptionDraft{id: subscriptionID}
+}
+
+func (h *BotHandlers) draft(telegramID int64) (subscriptionDraft, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	draft, ok := h.drafts[telegramID]
+	return draft, ok
+}
+
+func (h *BotHandlers) finishDraft(telegramID int64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.drafts, telegramID)
+}
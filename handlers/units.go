@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"context"
+
+	"github.com/ViolettaBykova/viot-tg-sirius/models/units"
+	"github.com/ViolettaBykova/viot-tg-sirius/pkg/i18n"
+	tb "gopkg.in/tucnak/telebot.v2"
+)
+
+// UnitsButton — кнопка выбора единиц измерения
+var UnitsButton = tb.InlineButton{Unique: "units"}
+
+var unitsChoices = []units.Units{units.Metric, units.Imperial, units.Standard}
+
+// HandleUnits обрабатывает команду /units
+func (h *BotHandlers) HandleUnits(m *tb.Message) {
+	lang := h.lang(context.TODO(), m.Sender)
+	keyboard := make([][]tb.InlineButton, 0, len(unitsChoices))
+	for _, u := range unitsChoices {
+		btn := *UnitsButton.With(string(u))
+		btn.Text = i18n.T(lang, "units.name."+string(u))
+		keyboard = append(keyboard, []tb.InlineButton{btn})
+	}
+
+	h.Bot.Send(m.Sender, i18n.T(lang, "units.choose"), &tb.ReplyMarkup{
+		InlineKeyboard: keyboard,
+	})
+}
+
+// HandleUnitsChoice обрабатывает выбор единиц измерения
+func (h *BotHandlers) HandleUnitsChoice(c *tb.Callback) {
+	ctx := context.TODO()
+	lang := h.lang(ctx, c.Sender)
+	h.Bot.Respond(c)
+
+	u := units.Units(c.Data)
+	if err := h.botService.SetUnits(ctx, c.Sender.ID, u); err != nil {
+		h.Bot.Edit(c.Message, i18n.T(lang, "error.save_setting"))
+		return
+	}
+	h.Bot.Edit(c.Message, i18n.T(lang, "units.set", i18n.T(lang, "units.name."+string(u))))
+}
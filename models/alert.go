@@ -0,0 +1,16 @@
+package models
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// SentAlert — отметка о том, что предупреждение о погоде уже отправлено пользователю
+type SentAlert struct {
+	bun.BaseModel `bun:"table:weather_alerts_sent"`
+	AlertID       string    `bun:"alert_id,pk"`
+	TelegramID    int64     `bun:"telegram_id,pk"`
+	ExpiresAt     time.Time `bun:"expires_at,notnull"` // После этого времени отметку можно удалить
+	SentAt        time.Time `bun:"sent_at,notnull,default:current_timestamp"`
+}
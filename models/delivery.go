@@ -0,0 +1,39 @@
+package models
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// DeliveryStatus — состояние уведомления в очереди на отправку
+type DeliveryStatus string
+
+const (
+	DeliveryPending DeliveryStatus = "pending" // Ждет отправки, в том числе повторной
+	DeliverySending DeliveryStatus = "sending" // Отправляется, повторно не берется
+	DeliverySent    DeliveryStatus = "sent"    // Доставлено в Telegram
+	DeliverySkipped DeliveryStatus = "skipped" // Не отправлялось: пауза, тихие часы или политика после простоя
+	DeliveryDead    DeliveryStatus = "dead"    // Попытки закончились, больше не отправляется
+)
+
+// Delivery — уведомление по подписке за одно срабатывание расписания и журнал его отправки.
+// Запись создается в одной транзакции с переносом времени следующего уведомления подписки.
+type Delivery struct {
+	bun.BaseModel  `bun:"table:deliveries"`
+	ID             int64          `bun:"id,pk,autoincrement"`
+	SubscriptionID int64          `bun:"subscription_id,notnull"`
+	TelegramID     int64          `bun:"telegram_id,notnull"`
+	ScheduledAt    time.Time      `bun:"scheduled_at,notnull"`      // Срабатывание расписания, за которое отправляется уведомление
+	Header         string         `bun:"header,notnull,default:''"` // Текст перед погодой, например сводка о пропущенном
+	Status         DeliveryStatus `bun:"status,notnull,default:'pending'"`
+	Attempts       int            `bun:"attempts,notnull,default:0"`
+	MessageID      *int           `bun:"message_id"`                    // ID сообщения в Telegram после отправки
+	LastError      string         `bun:"last_error,notnull,default:''"` // Ошибка последней попытки или причина пропуска
+	NextAttemptAt  time.Time      `bun:"next_attempt_at,notnull,default:current_timestamp"`
+	SentAt         *time.Time     `bun:"sent_at"`
+	CreatedAt      time.Time      `bun:"created_at,notnull,default:current_timestamp"`
+	UpdatedAt      time.Time      `bun:"updated_at,notnull,default:current_timestamp"`
+
+	Subscription *Subscription `bun:"rel:belongs-to,join:subscription_id=id"`
+}
@@ -6,4 +6,8 @@ const (
 	SceneDefault        Scene = "default"         // Состояние по умолчанию
 	SceneEnterCity      Scene = "enter_city"      // Ввод города
 	SceneSelectInterval Scene = "select_interval" // Выбор интервала
+
+	SceneSubscriptionCity       Scene = "subscription_city"       // Ввод города подписки
+	SceneSubscriptionSchedule   Scene = "subscription_schedule"   // Ввод расписания подписки
+	SceneSubscriptionThresholds Scene = "subscription_thresholds" // Ввод порогов изменения погоды
 )
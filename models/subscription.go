@@ -0,0 +1,47 @@
+package models
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// Format — вид сообщения с погодой в подписке
+type Format string
+
+const (
+	FormatFull  Format = "full"  // Подробный многострочный отчет
+	FormatShort Format = "short" // Одна строка: температура и описание
+)
+
+// WeatherSnapshot — погода, отправленная по подписке последней. С ней сравнивается новая погода
+// в режиме уведомлений только при заметных изменениях.
+type WeatherSnapshot struct {
+	Temp      float64   `json:"temp"`       // Температура, °C
+	WindSpeed float64   `json:"wind_speed"` // Скорость ветра, м/с
+	Condition string    `json:"condition"`  // Класс погодных условий
+	SentAt    time.Time `json:"sent_at"`
+}
+
+// Subscription — подписка пользователя на обновления погоды в одном месте по своему расписанию
+type Subscription struct {
+	bun.BaseModel   `bun:"table:subscriptions"`
+	ID              int64            `bun:"id,pk,autoincrement"`
+	TelegramID      int64            `bun:"telegram_id,notnull"`
+	City            string           `bun:"city,notnull"`
+	Lat             *float64         `bun:"lat"`                                  // Широта места, nil если не задана
+	Lon             *float64         `bun:"lon"`                                  // Долгота места, nil если не задана
+	Schedule        string           `bun:"schedule,notnull"`                     // Расписание в виде, который возвращает ParseSchedule
+	Format          Format           `bun:"format,notnull,default:'full'"`        // Вид сообщения
+	Enabled         bool             `bun:"enabled,notnull,default:true"`         // Выключенные подписки не планируются
+	LastAQI         int              `bun:"last_aqi,notnull,default:0"`           // AQI при последней проверке
+	NextRunAt       *time.Time       `bun:"next_run_at"`                          // Следующее уведомление, nil — не запланировано
+	LastDeliveredAt *time.Time       `bun:"last_delivered_at"`                    // Последнее успешно отправленное уведомление
+	OnlyOnChange    bool             `bun:"only_on_change,notnull,default:false"` // Отправлять, только если погода заметно изменилась
+	TempThreshold   float64          `bun:"temp_threshold,notnull,default:3"`     // Изменение температуры, °C, 0 — не учитывать
+	WindThreshold   float64          `bun:"wind_threshold,notnull,default:5"`     // Изменение скорости ветра, м/с, 0 — не учитывать
+	LastSent        *WeatherSnapshot `bun:"last_sent,type:jsonb"`                 // Погода в последнем отправленном уведомлении
+	CreatedAt       time.Time        `bun:"created_at,notnull,default:current_timestamp"`
+
+	User *User `bun:"rel:belongs-to,join:telegram_id=telegram_id"`
+}
@@ -0,0 +1,91 @@
+package units
+
+type Units string
+
+const (
+	Metric   Units = "metric"   // °C, м/с, мм рт. ст.
+	Imperial Units = "imperial" // °F, mph, дюймы рт. ст.
+	Standard Units = "standard" // K, м/с, гПа
+)
+
+// Valid сообщает, что значение — одна из поддерживаемых систем единиц
+func (u Units) Valid() bool {
+	switch u {
+	case Metric, Imperial, Standard:
+		return true
+	default:
+		return false
+	}
+}
+
+// Temp переводит температуру из °C
+func (u Units) Temp(celsius float64) float64 {
+	switch u {
+	case Imperial:
+		return celsius*9/5 + 32
+	case Standard:
+		return celsius + 273.15
+	default:
+		return celsius
+	}
+}
+
+// TempUnit возвращает обозначение единицы температуры
+func (u Units) TempUnit() string {
+	switch u {
+	case Imperial:
+		return "°F"
+	case Standard:
+		return " K"
+	default:
+		return "°C"
+	}
+}
+
+// Speed переводит скорость из м/с
+func (u Units) Speed(ms float64) float64 {
+	if u == Imperial {
+		return ms * 2.236936
+	}
+	return ms
+}
+
+// SpeedUnit возвращает идентификатор единицы скорости ("ms" или "mph") для поиска обозначения в каталоге сообщений
+func (u Units) SpeedUnit() string {
+	if u == Imperial {
+		return "mph"
+	}
+	return "ms"
+}
+
+// Pressure переводит давление из гПа
+func (u Units) Pressure(hPa float64) float64 {
+	switch u {
+	case Imperial:
+		return hPa * 0.02953
+	case Standard:
+		return hPa
+	default:
+		return hPa * 0.750062
+	}
+}
+
+// PressureUnit возвращает идентификатор единицы давления ("mmHg", "inHg" или "hPa") для поиска обозначения в каталоге сообщений
+func (u Units) PressureUnit() string {
+	switch u {
+	case Imperial:
+		return "inHg"
+	case Standard:
+		return "hPa"
+	default:
+		return "mmHg"
+	}
+}
+
+// PressurePrecision возвращает число знаков после запятой для давления
+func (u Units) PressurePrecision() int {
+	if u == Imperial {
+		return 2
+	}
+	return 0
+}
@@ -4,16 +4,64 @@ import (
 	"time"
 
 	"github.com/ViolettaBykova/viot-tg-sirius/models/scenes"
+	"github.com/ViolettaBykova/viot-tg-sirius/models/units"
+	"github.com/ViolettaBykova/viot-tg-sirius/pkg/i18n"
 	"github.com/google/uuid"
 	"github.com/uptrace/bun"
 )
 
+// QuietMode — что делать с обновлениями по расписанию в тихие часы
+type QuietMode string
+
+const (
+	QuietSkip   QuietMode = "skip"   // Не отправлять, после тихих часов прислать сводку
+	QuietSilent QuietMode = "silent" // Отправлять без звука
+)
+
+// InactiveReason — почему бот больше не может писать пользователю, пустая — может
+type InactiveReason string
+
+const (
+	InactiveBlocked      InactiveReason = "blocked"        // Пользователь заблокировал бота
+	InactiveDeactivated  InactiveReason = "deactivated"    // Аккаунт пользователя удален
+	InactiveChatNotFound InactiveReason = "chat_not_found" // Telegram не нашел чат с пользователем
+	InactiveNotStarted   InactiveReason = "not_started"    // Пользователь не начинал диалог с ботом
+)
+
 type User struct {
 	bun.BaseModel  `bun:"table:users"`
-	ID             uuid.UUID    `bun:"id,pk,autoincrement"`
-	TelegramID     int64        `bun:"telegram_id,unique,notnull"`
-	City           string       `bun:"city"`
-	UpdateInterval string       `bun:"update_interval,notnull,default:'1 час'"`
-	CreatedAt      time.Time    `bun:"created_at,notnull,default:current_timestamp"`
-	Scene          scenes.Scene `bun:"scene,notnull,default:'default'"` // Добавлено поле для состояния
+	ID             uuid.UUID      `bun:"id,pk,autoincrement"`
+	TelegramID     int64          `bun:"telegram_id,unique,notnull"`
+	City           string         `bun:"city"`
+	Lat            *float64       `bun:"lat"` // Широта выбранного места, nil если не задана
+	Lon            *float64       `bun:"lon"` // Долгота выбранного места, nil если не задана
+	CreatedAt      time.Time      `bun:"created_at,notnull,default:current_timestamp"`
+	Scene          scenes.Scene   `bun:"scene,notnull,default:'default'"`        // Добавлено поле для состояния
+	ShowAirQuality bool           `bun:"show_air_quality,notnull,default:false"` // Добавлять качество воздуха в обновления
+	AQIAlertLevel  int            `bun:"aqi_alert_level,notnull,default:0"`      // Порог AQI для предупреждения, 0 — выключено
+	Units          units.Units    `bun:"units,notnull,default:'metric'"`         // Единицы измерения в сообщениях
+	Language       i18n.Lang      `bun:"language,notnull,default:'ru'"`          // Язык сообщений бота
+	Timezone       string         `bun:"timezone,notnull,default:''"`            // Часовой пояс IANA или смещение вида +03:00, пустой — не задан
+	TimezoneAuto   bool           `bun:"timezone_auto,notnull,default:false"`    // Часовой пояс определен по месту, а не задан командой /timezone
+	Paused         bool           `bun:"paused,notnull,default:false"`           // Уведомления приостановлены командой /pause
+	PausedUntil    *time.Time     `bun:"paused_until"`                           // Конец паузы, nil — до команды /resume
+	Stopped        bool           `bun:"stopped,notnull,default:false"`          // Уведомления остановлены командой /stop
+	QuietFrom      string         `bun:"quiet_from,notnull,default:''"`          // Начало тихих часов ЧЧ:ММ по местному времени, пустое — выключены
+	QuietTo        string         `bun:"quiet_to,notnull,default:''"`            // Конец тихих часов ЧЧ:ММ по местному времени
+	QuietMode      QuietMode      `bun:"quiet_mode,notnull,default:'skip'"`      // Что делать с обновлениями в тихие часы
+	QuietMissed    bool           `bun:"quiet_missed,notnull,default:false"`     // В тихие часы были пропущены обновления, нужна сводка
+	InactiveReason InactiveReason `bun:"inactive_reason,notnull,default:''"`     // Почему пользователь отключен, пустая — активен
+	InactiveSince  *time.Time     `bun:"inactive_since"`                         // Когда пользователь отключен
+
+	Subscriptions []*Subscription `bun:"rel:has-many,join:telegram_id=telegram_id"`
+}
+
+// Active сообщает, что бот может писать пользователю
+func (u *User) Active() bool {
+	return u.InactiveReason == ""
+}
+
+// IsPaused сообщает, приостановлены ли уведомления пользователя в момент now
+func (u *User) IsPaused(now time.Time) bool {
+	return u.Paused && (u.PausedUntil == nil || now.Before(*u.PausedUntil))
 }
@@ -0,0 +1,236 @@
+package i18n
+
+var en = map[string]string{
+	// Общие сообщения
+	"error.save_user":    "Failed to save your profile.",
+	"error.get_state":    "Failed to load your settings.",
+	"error.save_setting": "Failed to save the setting. Please try again.",
+	"command.unknown":    "Unknown command. Use /start to begin.",
+
+	// Ввод города и местоположения
+	"start.welcome":         "Welcome! Please enter a city to get weather updates, or share your location.",
+	"button.share_location": "📍 Share location",
+	"city.not_found":        "City not found. Please check the name and try again.",
+	"city.choose":           "Several places match this name. Please choose one:",
+	"city.list_expired":     "This list has expired. Please enter the city again.",
+	"city.chosen":           "Selected: %s",
+	"city.save_error":       "Failed to save the city. Please try again.",
+	"city.saved":            "City %s saved. Choose an update interval: %s.",
+	"location.save_error":   "Failed to save the location. Please try again.",
+	"location.updated":      "Location updated: %s.",
+
+	// Интервалы обновлений
+	"interval.choose":         "Choose an update interval: %s — or type your own schedule, for example “every 2 hours”, “weekdays at 8:00” or “0 8 * * 1-5”. To get the weather at specific times, use /time.",
+	"interval.schedule_error": "Failed to schedule updates. Please try again.",
+	"interval.30s":            "30 seconds",
+	"interval.1m":             "1 minute",
+	"interval.15m":            "15 minutes",
+	"interval.1h":             "1 hour",
+	"interval.6h":             "6 hours",
+	"interval.12h":            "12 hours",
+
+	// Расписание
+	"schedule.invalid":      "Couldn't read the schedule. Examples: “1 hour”, “every 2 hours”, “every 45m”, “weekdays at 8:00”, “mon, wed, fri at 7:30 and 19:00”, “0 8 * * 1-5”.",
+	"schedule.too_frequent": "This schedule is too frequent: updates can't come more often than once every %s.",
+	"schedule.set":          "Schedule saved (time zone %s). Next updates:\n%s",
+	"schedule.set_no_runs":  "Schedule saved (time zone %s).",
+	"schedule.no_timezone":  "Your time zone is unknown, and it is needed for updates at a time of day. Enter your city or set the time zone with /timezone, for example /timezone Europe/London.",
+	"schedule.every":        "every %s",
+	"schedule.daily":        "daily at %s",
+	"schedule.on_days":      "%s at %s",
+	"schedule.weekdays":     "weekdays",
+	"schedule.weekends":     "weekends",
+	"schedule.cron":         "cron rule “%s”",
+	"duration.days":         "%d d",
+	"duration.hours":        "%d h",
+	"duration.minutes":      "%d min",
+	"duration.seconds":      "%d s",
+	"weekday_short.0":       "Sun",
+	"weekday_short.1":       "Mon",
+	"weekday_short.2":       "Tue",
+	"weekday_short.3":       "Wed",
+	"weekday_short.4":       "Thu",
+	"weekday_short.5":       "Fri",
+	"weekday_short.6":       "Sat",
+
+	// Подписки
+	"subscriptions.title":             "Your subscriptions:",
+	"subscriptions.empty":             "You have no subscriptions yet. Add one to get the weather on a schedule.",
+	"subscriptions.item":              "%d. %s — %s%s",
+	"subscriptions.disabled":          " (off)",
+	"subscription.details":            "📍 %s\n🕒 %s\n📄 Format: %s\nState: %s",
+	"subscription.enabled":            "on",
+	"subscription.disabled":           "off",
+	"subscription.enter_city":         "Enter a city for the new subscription or share your location.",
+	"subscription.change_city":        "Enter a new city for the subscription or share your location.",
+	"subscription.enter_schedule":     "Choose a schedule: %s — or type your own, for example “every 2 hours” or “weekdays at 8:00”.",
+	"subscription.city_chosen":        "Subscription city: %s. Choose a schedule: %s — or type your own, for example “every 2 hours” or “weekdays at 8:00”.",
+	"subscription.location_set":       "Subscription location: %s.",
+	"subscription.removed":            "Subscription removed.",
+	"subscription.not_found":          "Subscription not found. Open the list again: /subscriptions.",
+	"subscription.city_not_found":     "Couldn't find %s, the subscription is turned off. Change its location in /subscriptions.",
+	"subscription.only_on_change":     "🔕 Only on noticeable changes: temperature by %s, wind by %s, or a change in precipitation",
+	"subscription.threshold_off":      "ignored",
+	"subscription.enter_thresholds":   "How much should the temperature (%s) and wind (%s) change to send an update? Send two numbers, for example: 3 5. 0 means ignore.",
+	"subscription.thresholds_set":     "Thresholds saved.",
+	"subscription.thresholds_invalid": "Couldn't read the thresholds. Send two numbers from 0 to 100, for example: 3 5.",
+	"format.full":                     "full",
+	"format.short":                    "short",
+	"button.subscription_add":         "➕ Add",
+	"button.schedule":                 "🕒 Schedule",
+	"button.location":                 "📍 Location",
+	"button.format":                   "📄 Format: %s",
+	"button.enable":                   "▶️ Turn on",
+	"button.disable":                  "⏸ Turn off",
+	"button.only_on_change":           "🔕 Only on changes",
+	"button.always":                   "🔔 Always send",
+	"button.thresholds":               "📏 Thresholds",
+	"button.remove":                   "🗑 Remove",
+	"button.back":                     "⬅️ Back",
+
+	// Пауза и остановка уведомлений
+	"pause.set":       "Notifications are paused. To resume them, use /resume.",
+	"pause.set_until": "Notifications are paused until %s. To resume them earlier, use /resume.",
+	"pause.invalid":   "Couldn't read the pause duration. Examples: /pause, /pause 2 hours, /pause until 18:00, /pause until Monday, /pause until 20.10.",
+	"resume.done":     "Notifications resumed.",
+	"stop.done":       "Notifications are stopped, your subscriptions are kept. To get the weather again, use /resume.",
+
+	// Тихие часы
+	"quiet.off":           "Quiet hours are off. To turn them on, send, for example, /quiet 23:00-07:00.",
+	"quiet.current":       "Quiet hours: from %s to %s local time, updates during them: %s.\nChange: /quiet 23:00-07:00, turn off: /quiet off.",
+	"quiet.set":           "Quiet hours: from %s to %s local time. What should happen to updates during them?",
+	"quiet.disabled":      "Quiet hours are off.",
+	"quiet.invalid":       "Couldn't read the quiet hours. Example: /quiet 23:00-07:00 or /quiet off.",
+	"quiet.mode.skip":     "skip them and send a summary afterwards",
+	"quiet.mode.silent":   "send them silently",
+	"quiet.mode_set":      "Updates during quiet hours: %s.",
+	"quiet.summary":       "🌙 Quiet hours are over. The weather now:",
+	"button.quiet.skip":   "🔕 Skip",
+	"button.quiet.silent": "🔈 Silently",
+
+	// Уведомления после простоя бота
+	"catchup.digest":         "⏱ While the bot was down, %d updates were missed (from %s to %s).",
+	"catchup.last_delivered": "The last update arrived on %s.",
+	"catchup.now":            "The weather now:",
+
+	// Ежедневные уведомления
+	"time.help": "Set the time of daily updates in your local time, for example: /time 07:30 or /time 08:00 20:00.\n" +
+		"The time zone is detected from your city; change it with /timezone.",
+	"time.invalid":     "Couldn't read the time. Use the HH:MM format, for example 07:30.",
+	"time.too_many":    "You can set at most %d times a day.",
+	"timezone.current": "Time zone: %s. To change it, send for example /timezone Europe/London or /timezone +01:00.",
+	"timezone.not_set": "No time zone set; it will be detected from your city. To set it, send for example /timezone Europe/London or /timezone +01:00.",
+	"timezone.invalid": "Unknown time zone. Use an IANA name such as Europe/London, or a UTC offset such as +01:00.",
+	"timezone.set":     "Time zone: %s.",
+
+	// Отчет о погоде
+	"report.title":      "%s Weather in %s: %s",
+	"report.temp":       "🌡 Temperature: %s, feels like %s",
+	"report.humidity":   "💧 Humidity: %d%%",
+	"report.wind":       "🌬 Wind: %s, %s",
+	"report.gust":       ", gusts up to %s",
+	"report.beaufort":   " (force %d — %s)",
+	"report.pressure":   "🔽 Pressure: %s",
+	"report.clouds":     "☁️ Cloud cover: %d%%",
+	"report.visibility": "👁 Visibility: %.1f km",
+	"report.sun":        "🌅 Sunrise: %s, 🌇 sunset: %s",
+	"report.short":      "%s %s: %s, %s, wind %s",
+
+	// Прогноз
+	"forecast.title": "Weather forecast for %s:",
+	"forecast.day":   "%s: %s\nTemperature: %s to %s\nChance of precipitation: %.0f%%",
+	"day.today":      "Today",
+	"day.tomorrow":   "Tomorrow",
+	"weekday.0":      "Sunday",
+	"weekday.1":      "Monday",
+	"weekday.2":      "Tuesday",
+	"weekday.3":      "Wednesday",
+	"weekday.4":      "Thursday",
+	"weekday.5":      "Friday",
+	"weekday.6":      "Saturday",
+
+	// Направление ветра, 16 румбов
+	"compass.0":  "N",
+	"compass.1":  "NNE",
+	"compass.2":  "NE",
+	"compass.3":  "ENE",
+	"compass.4":  "E",
+	"compass.5":  "ESE",
+	"compass.6":  "SE",
+	"compass.7":  "SSE",
+	"compass.8":  "S",
+	"compass.9":  "SSW",
+	"compass.10": "SW",
+	"compass.11": "WSW",
+	"compass.12": "W",
+	"compass.13": "WNW",
+	"compass.14": "NW",
+	"compass.15": "NNW",
+
+	// Шкала Бофорта
+	"beaufort.0":  "calm",
+	"beaufort.1":  "light air",
+	"beaufort.2":  "light breeze",
+	"beaufort.3":  "gentle breeze",
+	"beaufort.4":  "moderate breeze",
+	"beaufort.5":  "fresh breeze",
+	"beaufort.6":  "strong breeze",
+	"beaufort.7":  "near gale",
+	"beaufort.8":  "gale",
+	"beaufort.9":  "strong gale",
+	"beaufort.10": "storm",
+	"beaufort.11": "violent storm",
+	"beaufort.12": "hurricane",
+
+	// Единицы измерения
+	"units.choose":        "Choose units:",
+	"units.set":           "Units: %s.",
+	"units.name.metric":   "°C, m/s, mmHg",
+	"units.name.imperial": "°F, mph, inHg",
+	"units.name.standard": "K, m/s, hPa",
+	"unit.ms":             "m/s",
+	"unit.mph":            "mph",
+	"unit.mmHg":           "mmHg",
+	"unit.inHg":           "inHg",
+	"unit.hPa":            "hPa",
+
+	// Качество воздуха
+	"air.help": "Air quality commands:\n" +
+		"/air — current air quality\n" +
+		"/air on — include air quality in updates\n" +
+		"/air off — don't include air quality in updates\n" +
+		"/air alert N — warn me when AQI reaches N (1–5), 0 disables warnings",
+	"air.show_on":     "Air quality will be included in updates.",
+	"air.show_off":    "Air quality will no longer be included in updates.",
+	"air.level_range": "The level must be a number from 0 to %d.",
+	"air.alerts_off":  "Air quality warnings are off.",
+	"air.alerts_on":   "I'll warn you when air quality becomes “%s” (AQI %d) or worse.",
+	"air.report":      "🏭 Air quality in %s: %s (AQI %d)\n\nPM2.5: %.1f µg/m³\nPM10: %.1f µg/m³\nO₃: %.1f µg/m³\nNO₂: %.1f µg/m³",
+	"air.warning":     "⚠️ Air quality in %s has worsened: %s (AQI %d).\nPM2.5: %.1f µg/m³, PM10: %.1f µg/m³",
+	"air.line":        "🏭 Air quality: %s (AQI %d)",
+	"aqi.0":           "no data",
+	"aqi.1":           "good",
+	"aqi.2":           "fair",
+	"aqi.3":           "moderate",
+	"aqi.4":           "poor",
+	"aqi.5":           "very poor",
+
+	// Предупреждения о неблагоприятной погоде
+	"alert.title":  "⚠️ Weather alert for %s: %s",
+	"alert.period": "In effect from %s to %s",
+	"alert.source": "Source: %s",
+
+	// Язык
+	"language.choose": "Choose a language:",
+	"language.set":    "Language: %s.",
+
+	// Ошибки получения погоды
+	"error.city_not_set":    "No city set. Please enter a city.",
+	"error.air_unavailable": "Air quality data is not available.",
+	"error.city_not_found":  "City not found. Please enter the city again.",
+	"error.quota":           "The weather service is overloaded. Please try later; the next update will arrive on schedule.",
+	"error.api_key":         "The weather service is temporarily unavailable due to a bot configuration error. We're on it.",
+	"error.unavailable":     "The weather service is unavailable right now. Please try later.",
+	"error.decode":          "The weather service returned an invalid response. Please try later.",
+	"error.generic":         "Failed to get weather data.",
+}
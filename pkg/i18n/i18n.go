@@ -0,0 +1,92 @@
+package i18n
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Lang — язык сообщений бота
+type Lang string
+
+const (
+	RU Lang = "ru"
+	EN Lang = "en"
+
+	// Default — язык по умолчанию для пользователей без сохраненного языка
+	Default = RU
+)
+
+// Supported — языки, для которых есть каталог сообщений
+var Supported = []Lang{RU, EN}
+
+var catalogs = map[Lang]map[string]string{
+	RU: ru,
+	EN: en,
+}
+
+func init() {
+	// Каталоги должны содержать одинаковый набор ключей, иначе пользователь увидит ключ вместо текста
+	for key := range ru {
+		if _, ok := en[key]; !ok {
+			panic(fmt.Sprintf("i18n: key %q is missing in en catalog", key))
+		}
+	}
+	for key := range en {
+		if _, ok := ru[key]; !ok {
+			panic(fmt.Sprintf("i18n: key %q is missing in ru catalog", key))
+		}
+	}
+}
+
+// Valid сообщает, что для языка есть каталог сообщений
+func (l Lang) Valid() bool {
+	_, ok := catalogs[l]
+	return ok
+}
+
+// Name возвращает название языка на нем самом
+func (l Lang) Name() string {
+	switch l {
+	case EN:
+		return "English"
+	default:
+		return "Русский"
+	}
+}
+
+// FromTelegram выбирает язык по language_code пользователя Telegram (например, "ru", "en-US").
+// Для русскоязычного региона и пустого кода возвращает русский, для остальных — английский.
+func FromTelegram(code string) Lang {
+	code = strings.ToLower(code)
+	if i := strings.IndexAny(code, "-_"); i >= 0 {
+		code = code[:i]
+	}
+	switch code {
+	case "":
+		return Default
+	case "ru", "uk", "be", "kk":
+		return RU
+	case "en":
+		return EN
+	}
+	if lang := Lang(code); lang.Valid() {
+		return lang
+	}
+	return EN
+}
+
+// T возвращает сообщение key на языке lang, подставляя args в шаблон.
+// Если сообщения нет на языке lang, используется язык по умолчанию, а если нет и там — сам ключ.
+func T(lang Lang, key string, args ...any) string {
+	tmpl, ok := catalogs[lang][key]
+	if !ok {
+		tmpl, ok = catalogs[Default][key]
+	}
+	if !ok {
+		return key
+	}
+	if len(args) == 0 {
+		return tmpl
+	}
+	return fmt.Sprintf(tmpl, args...)
+}
@@ -0,0 +1,236 @@
+package i18n
+
+var ru = map[string]string{
+	// Общие сообщения
+	"error.save_user":    "Ошибка при сохранении пользователя.",
+	"error.get_state":    "Ошибка при получении состояния пользователя.",
+	"error.save_setting": "Ошибка при сохранении настройки. Попробуйте еще раз.",
+	"command.unknown":    "Команда не распознана. Используйте /start для начала.",
+
+	// Ввод города и местоположения
+	"start.welcome":         "Добро пожаловать! Пожалуйста, введите город для получения прогноза погоды или поделитесь местоположением.",
+	"button.share_location": "📍 Отправить местоположение",
+	"city.not_found":        "Город не найден. Проверьте название и попробуйте еще раз.",
+	"city.choose":           "Найдено несколько городов с таким названием. Выберите нужный:",
+	"city.list_expired":     "Список устарел. Пожалуйста, введите город еще раз.",
+	"city.chosen":           "Выбрано: %s",
+	"city.save_error":       "Ошибка при сохранении города. Попробуйте еще раз.",
+	"city.saved":            "Город %s сохранен. Выберите интервал обновления: %s.",
+	"location.save_error":   "Ошибка при сохранении местоположения. Попробуйте еще раз.",
+	"location.updated":      "Местоположение обновлено: %s.",
+
+	// Интервалы обновлений
+	"interval.choose":         "Выберите интервал обновления: %s — или напишите свое расписание, например «каждые 2 часа», «по будням в 8:00» или «0 8 * * 1-5». Чтобы получать погоду в определенное время, используйте /time.",
+	"interval.schedule_error": "Ошибка при планировании обновлений. Попробуйте еще раз.",
+	"interval.30s":            "30 секунд",
+	"interval.1m":             "1 минута",
+	"interval.15m":            "15 минут",
+	"interval.1h":             "1 час",
+	"interval.6h":             "6 часов",
+	"interval.12h":            "12 часов",
+
+	// Расписание
+	"schedule.invalid":      "Не удалось разобрать расписание. Примеры: «1 час», «каждые 2 часа», «every 45m», «по будням в 8:00», «пн, ср, пт в 7:30 и 19:00», «0 8 * * 1-5».",
+	"schedule.too_frequent": "Слишком частое расписание: уведомления можно получать не чаще одного раза в %s.",
+	"schedule.set":          "Расписание сохранено (часовой пояс %s). Ближайшие уведомления:\n%s",
+	"schedule.set_no_runs":  "Расписание сохранено (часовой пояс %s).",
+	"schedule.no_timezone":  "Часовой пояс неизвестен, а он нужен для уведомлений по времени суток. Укажите свой город или задайте пояс командой /timezone, например /timezone Europe/Moscow.",
+	"schedule.every":        "каждые %s",
+	"schedule.daily":        "ежедневно в %s",
+	"schedule.on_days":      "%s в %s",
+	"schedule.weekdays":     "по будням",
+	"schedule.weekends":     "по выходным",
+	"schedule.cron":         "по правилу cron «%s»",
+	"duration.days":         "%d дн.",
+	"duration.hours":        "%d ч",
+	"duration.minutes":      "%d мин",
+	"duration.seconds":      "%d с",
+	"weekday_short.0":       "вс",
+	"weekday_short.1":       "пн",
+	"weekday_short.2":       "вт",
+	"weekday_short.3":       "ср",
+	"weekday_short.4":       "чт",
+	"weekday_short.5":       "пт",
+	"weekday_short.6":       "сб",
+
+	// Подписки
+	"subscriptions.title":             "Ваши подписки:",
+	"subscriptions.empty":             "У вас пока нет подписок. Добавьте первую, чтобы получать погоду по расписанию.",
+	"subscriptions.item":              "%d. %s — %s%s",
+	"subscriptions.disabled":          " (выключена)",
+	"subscription.details":            "📍 %s\n🕒 %s\n📄 Формат: %s\nСостояние: %s",
+	"subscription.enabled":            "включена",
+	"subscription.disabled":           "выключена",
+	"subscription.enter_city":         "Введите город для новой подписки или поделитесь местоположением.",
+	"subscription.change_city":        "Введите новый город для подписки или поделитесь местоположением.",
+	"subscription.enter_schedule":     "Выберите расписание: %s — или напишите свое, например «каждые 2 часа» или «по будням в 8:00».",
+	"subscription.city_chosen":        "Город подписки: %s. Выберите расписание: %s — или напишите свое, например «каждые 2 часа» или «по будням в 8:00».",
+	"subscription.location_set":       "Место подписки: %s.",
+	"subscription.removed":            "Подписка удалена.",
+	"subscription.not_found":          "Подписка не найдена. Откройте список заново: /subscriptions.",
+	"subscription.city_not_found":     "Не удалось найти город %s, подписка выключена. Измените место в /subscriptions.",
+	"subscription.only_on_change":     "🔕 Только при заметных изменениях: температура на %s, ветер на %s или смена осадков",
+	"subscription.threshold_off":      "не учитывается",
+	"subscription.enter_thresholds":   "На сколько должны измениться температура (%s) и ветер (%s), чтобы прислать обновление? Отправьте два числа, например: 3 5. 0 — не учитывать.",
+	"subscription.thresholds_set":     "Пороги сохранены.",
+	"subscription.thresholds_invalid": "Не удалось разобрать пороги. Отправьте два числа от 0 до 100, например: 3 5.",
+	"format.full":                     "полный",
+	"format.short":                    "краткий",
+	"button.subscription_add":         "➕ Добавить",
+	"button.schedule":                 "🕒 Расписание",
+	"button.location":                 "📍 Место",
+	"button.format":                   "📄 Формат: %s",
+	"button.enable":                   "▶️ Включить",
+	"button.disable":                  "⏸ Выключить",
+	"button.only_on_change":           "🔕 Только при изменениях",
+	"button.always":                   "🔔 Присылать всегда",
+	"button.thresholds":               "📏 Пороги",
+	"button.remove":                   "🗑 Удалить",
+	"button.back":                     "⬅️ Назад",
+
+	// Пауза и остановка уведомлений
+	"pause.set":       "Уведомления приостановлены. Чтобы возобновить их, используйте /resume.",
+	"pause.set_until": "Уведомления приостановлены до %s. Чтобы возобновить их раньше, используйте /resume.",
+	"pause.invalid":   "Не удалось разобрать срок паузы. Примеры: /pause, /pause 2 часа, /pause до 18:00, /pause до понедельника, /pause до 20.10.",
+	"resume.done":     "Уведомления возобновлены.",
+	"stop.done":       "Уведомления остановлены, подписки сохранены. Чтобы снова получать погоду, используйте /resume.",
+
+	// Тихие часы
+	"quiet.off":           "Тихие часы выключены. Чтобы включить их, отправьте, например, /quiet 23:00-07:00.",
+	"quiet.current":       "Тихие часы: с %s до %s по местному времени, обновления в это время: %s.\nИзменить: /quiet 23:00-07:00, выключить: /quiet off.",
+	"quiet.set":           "Тихие часы: с %s до %s по местному времени. Что делать с обновлениями в это время?",
+	"quiet.disabled":      "Тихие часы выключены.",
+	"quiet.invalid":       "Не удалось разобрать тихие часы. Пример: /quiet 23:00-07:00 или /quiet off.",
+	"quiet.mode.skip":     "пропускать, а после тихих часов прислать сводку",
+	"quiet.mode.silent":   "присылать без звука",
+	"quiet.mode_set":      "Обновления в тихие часы: %s.",
+	"quiet.summary":       "🌙 Тихие часы закончились. Погода сейчас:",
+	"button.quiet.skip":   "🔕 Пропускать",
+	"button.quiet.silent": "🔈 Без звука",
+
+	// Уведомления после простоя бота
+	"catchup.digest":         "⏱ Пока бот не работал, пропущено обновлений: %d (с %s по %s).",
+	"catchup.last_delivered": "Последнее обновление пришло %s.",
+	"catchup.now":            "Погода сейчас:",
+
+	// Ежедневные уведомления
+	"time.help": "Укажите время ежедневных уведомлений по местному времени, например: /time 07:30 или /time 08:00 20:00.\n" +
+		"Часовой пояс определяется по вашему городу, изменить его можно командой /timezone.",
+	"time.invalid":     "Не удалось разобрать время. Используйте формат ЧЧ:ММ, например 07:30.",
+	"time.too_many":    "Можно указать не больше %d времен в день.",
+	"timezone.current": "Часовой пояс: %s. Чтобы изменить его, отправьте, например, /timezone Europe/Moscow или /timezone +03:00.",
+	"timezone.not_set": "Часовой пояс не задан, он будет определен по вашему городу. Чтобы указать его, отправьте, например, /timezone Europe/Moscow или /timezone +03:00.",
+	"timezone.invalid": "Неизвестный часовой пояс. Используйте название из базы IANA, например Europe/Moscow, или смещение от UTC, например +03:00.",
+	"timezone.set":     "Часовой пояс: %s.",
+
+	// Отчет о погоде
+	"report.title":      "%s Погода в %s: %s",
+	"report.temp":       "🌡 Температура: %s, ощущается как %s",
+	"report.humidity":   "💧 Влажность: %d%%",
+	"report.wind":       "🌬 Ветер: %s, %s",
+	"report.gust":       ", порывы до %s",
+	"report.beaufort":   " (%d балл. — %s)",
+	"report.pressure":   "🔽 Давление: %s",
+	"report.clouds":     "☁️ Облачность: %d%%",
+	"report.visibility": "👁 Видимость: %.1f км",
+	"report.sun":        "🌅 Восход: %s, 🌇 закат: %s",
+	"report.short":      "%s %s: %s, %s, ветер %s",
+
+	// Прогноз
+	"forecast.title": "Прогноз погоды в %s:",
+	"forecast.day":   "%s: %s\nТемпература: от %s до %s\nВероятность осадков: %.0f%%",
+	"day.today":      "Сегодня",
+	"day.tomorrow":   "Завтра",
+	"weekday.0":      "Воскресенье",
+	"weekday.1":      "Понедельник",
+	"weekday.2":      "Вторник",
+	"weekday.3":      "Среда",
+	"weekday.4":      "Четверг",
+	"weekday.5":      "Пятница",
+	"weekday.6":      "Суббота",
+
+	// Направление ветра, 16 румбов
+	"compass.0":  "С",
+	"compass.1":  "ССВ",
+	"compass.2":  "СВ",
+	"compass.3":  "ВСВ",
+	"compass.4":  "В",
+	"compass.5":  "ВЮВ",
+	"compass.6":  "ЮВ",
+	"compass.7":  "ЮЮВ",
+	"compass.8":  "Ю",
+	"compass.9":  "ЮЮЗ",
+	"compass.10": "ЮЗ",
+	"compass.11": "ЗЮЗ",
+	"compass.12": "З",
+	"compass.13": "ЗСЗ",
+	"compass.14": "СЗ",
+	"compass.15": "ССЗ",
+
+	// Шкала Бофорта
+	"beaufort.0":  "штиль",
+	"beaufort.1":  "тихий",
+	"beaufort.2":  "легкий",
+	"beaufort.3":  "слабый",
+	"beaufort.4":  "умеренный",
+	"beaufort.5":  "свежий",
+	"beaufort.6":  "сильный",
+	"beaufort.7":  "крепкий",
+	"beaufort.8":  "очень крепкий",
+	"beaufort.9":  "шторм",
+	"beaufort.10": "сильный шторм",
+	"beaufort.11": "жестокий шторм",
+	"beaufort.12": "ураган",
+
+	// Единицы измерения
+	"units.choose":        "Выберите единицы измерения:",
+	"units.set":           "Единицы измерения: %s.",
+	"units.name.metric":   "°C, м/с, мм рт. ст.",
+	"units.name.imperial": "°F, mph, дюймы рт. ст.",
+	"units.name.standard": "K, м/с, гПа",
+	"unit.ms":             "м/с",
+	"unit.mph":            "mph",
+	"unit.mmHg":           "мм рт. ст.",
+	"unit.inHg":           "дюйм. рт. ст.",
+	"unit.hPa":            "гПа",
+
+	// Качество воздуха
+	"air.help": "Команды качества воздуха:\n" +
+		"/air — текущее качество воздуха\n" +
+		"/air вкл — добавлять качество воздуха в обновления\n" +
+		"/air выкл — не добавлять качество воздуха в обновления\n" +
+		"/air порог N — предупреждать, когда AQI достигнет N (1–5), 0 — не предупреждать",
+	"air.show_on":     "Качество воздуха будет добавляться в обновления.",
+	"air.show_off":    "Качество воздуха больше не будет добавляться в обновления.",
+	"air.level_range": "Порог должен быть числом от 0 до %d.",
+	"air.alerts_off":  "Предупреждения о качестве воздуха выключены.",
+	"air.alerts_on":   "Предупрежу, когда качество воздуха станет «%s» (AQI %d) или хуже.",
+	"air.report":      "🏭 Качество воздуха в %s: %s (AQI %d)\n\nPM2.5: %.1f мкг/м³\nPM10: %.1f мкг/м³\nO₃: %.1f мкг/м³\nNO₂: %.1f мкг/м³",
+	"air.warning":     "⚠️ Качество воздуха в %s ухудшилось: %s (AQI %d).\nPM2.5: %.1f мкг/м³, PM10: %.1f мкг/м³",
+	"air.line":        "🏭 Качество воздуха: %s (AQI %d)",
+	"aqi.0":           "нет данных",
+	"aqi.1":           "хорошее",
+	"aqi.2":           "удовлетворительное",
+	"aqi.3":           "умеренное",
+	"aqi.4":           "плохое",
+	"aqi.5":           "очень плохое",
+
+	// Предупреждения о неблагоприятной погоде
+	"alert.title":  "⚠️ Предупреждение о погоде в %s: %s",
+	"alert.period": "Действует с %s до %s",
+	"alert.source": "Источник: %s",
+
+	// Язык
+	"language.choose": "Выберите язык:",
+	"language.set":    "Язык: %s.",
+
+	// Ошибки получения погоды
+	"error.city_not_set":    "Город не указан. Пожалуйста, введите город.",
+	"error.air_unavailable": "Данные о качестве воздуха недоступны.",
+	"error.city_not_found":  "Город не найден. Пожалуйста, введите город заново.",
+	"error.quota":           "Сервис погоды перегружен запросами. Попробуйте позже, следующее обновление придет по расписанию.",
+	"error.api_key":         "Сервис погоды временно недоступен из-за ошибки настройки бота. Мы уже разбираемся.",
+	"error.unavailable":     "Сервис погоды сейчас недоступен. Попробуйте позже.",
+	"error.decode":          "Сервис погоды вернул некорректный ответ. Попробуйте позже.",
+	"error.generic":         "Ошибка получения данных о погоде.",
+}
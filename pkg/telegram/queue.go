@@ -0,0 +1,355 @@
+package telegram
+
+import (
+	"container/list"
+	"errors"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	tb "gopkg.in/tucnak/telebot.v2"
+)
+
+const (
+	// DefaultGlobalRate — сколько сообщений в секунду по умолчанию отправляет бот. Telegram допускает около 30.
+	DefaultGlobalRate = 25
+	// DefaultChatInterval — промежуток по умолчанию между сообщениями в один чат. Telegram допускает около одного в секунду.
+	DefaultChatInterval = time.Second
+	// DefaultFloodRetries — сколько раз по умолчанию повторять сообщение, на которое Telegram ответил 429
+	DefaultFloodRetries = 3
+)
+
+// floodDelay — пауза после ответа 429 без retry_after
+const floodDelay = 5 * time.Second
+
+// ErrQueueStopped возвращается для сообщений, которые не успели отправить до остановки очереди
+var ErrQueueStopped = errors.New("send queue is stopped")
+
+// Priority — приоритет сообщения в очереди
+type Priority int
+
+const (
+	PriorityInteractive Priority = iota // Ответы на действия пользователя
+	PriorityScheduled                   // Уведомления по расписанию и рассылки
+
+	numPriorities = iota
+)
+
+type QueueOption func(q *Queue)
+
+// WithGlobalRate задает, сколько сообщений в секунду очередь отправляет во все чаты вместе.
+// Ограничение действует в пределах процесса: если бот запущен в нескольких экземплярах,
+// предел Telegram для бота нужно разделить между ними.
+func WithGlobalRate(perSecond int) QueueOption {
+	return func(q *Queue) {
+		if perSecond > 0 {
+			q.rate = float64(perSecond)
+		}
+	}
+}
+
+// WithChatInterval задает минимальный промежуток между сообщениями в один чат
+func WithChatInterval(d time.Duration) QueueOption {
+	return func(q *Queue) {
+		if d >= 0 {
+			q.chatInterval = d
+		}
+	}
+}
+
+// WithFloodRetries задает, сколько раз повторять сообщение, на которое Telegram ответил 429
+func WithFloodRetries(n int) QueueOption {
+	return func(q *Queue) {
+		if n >= 0 {
+			q.floodRetries = n
+		}
+	}
+}
+
+// Queue — очередь исходящих сообщений бота. Через нее проходят все отправки, чтобы не превышать ограничения
+// Telegram: общий поток процесса ограничен маркерной корзиной, сообщения в один чат разнесены во времени,
+// а после ответа 429 очередь ждет указанные в retry_after секунды. Ответы пользователям отправляются
+// раньше уведомлений по расписанию.
+type Queue struct {
+	bot          *tb.Bot
+	rate         float64 // Маркеров в секунду, он же размер корзины
+	chatInterval time.Duration
+	floodRetries int
+
+	mu          sync.Mutex
+	queues      [numPriorities]*list.List
+	chatNext    map[string]time.Time // Когда можно отправить следующее сообщение в чат
+	pausedUntil time.Time            // До этого времени Telegram просил ничего не отправлять
+	tokens      float64
+	refilled    time.Time
+	stopped     bool
+
+	wake     chan struct{}
+	stop     chan struct{}
+	done     chan struct{}
+	inflight sync.WaitGroup
+
+	sent      atomic.Int64
+	throttled atomic.Int64
+}
+
+// QueueStats — состояние очереди
+type QueueStats struct {
+	Interactive int   // Ответов пользователям ждет отправки
+	Scheduled   int   // Уведомлений по расписанию ждет отправки
+	Sent        int64 // Отправлено сообщений
+	Throttled   int64 // Получено ответов 429
+}
+
+type request struct {
+	chat     string
+	priority Priority
+	send     func() (*tb.Message, error)
+	retries  int
+	result   chan result
+}
+
+type result struct {
+	msg *tb.Message
+	err error
+}
+
+// NewQueue создает очередь отправки сообщений через bot. Очередь начинает отправку после Start.
+func NewQueue(bot *tb.Bot, opts ...QueueOption) *Queue {
+	q := &Queue{
+		bot:          bot,
+		rate:         DefaultGlobalRate,
+		chatInterval: DefaultChatInterval,
+		floodRetries: DefaultFloodRetries,
+		chatNext:     make(map[string]time.Time),
+		wake:         make(chan struct{}, 1),
+		stop:         make(chan struct{}),
+		done:         make(chan struct{}),
+	}
+	for i := range q.queues {
+		q.queues[i] = list.New()
+	}
+	for _, applyOpt := range opts {
+		applyOpt(q)
+	}
+	q.tokens = q.rate
+	q.refilled = time.Now()
+	return q
+}
+
+// Start запускает отправку сообщений из очереди
+func (q *Queue) Start() {
+	go q.run()
+}
+
+// Stop останавливает очередь: ждет уже начатые отправки, а остальным сообщениям возвращает ErrQueueStopped
+func (q *Queue) Stop() {
+	q.mu.Lock()
+	q.stopped = true
+	q.mu.Unlock()
+	close(q.stop)
+	<-q.done
+	q.inflight.Wait()
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for _, queue := range q.queues {
+		for e := queue.Front(); e != nil; e = e.Next() {
+			e.Value.(*request).result <- result{err: ErrQueueStopped}
+		}
+		queue.Init()
+	}
+}
+
+// Stats возвращает глубину очереди и счетчики отправок
+func (q *Queue) Stats() QueueStats {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return QueueStats{
+		Interactive: q.queues[PriorityInteractive].Len(),
+		Scheduled:   q.queues[PriorityScheduled].Len(),
+		Sent:        q.sent.Load(),
+		Throttled:   q.throttled.Load(),
+	}
+}
+
+// Sender возвращает отправителя, сообщения которого встают в очередь с приоритетом priority
+func (q *Queue) Sender(priority Priority) *Sender {
+	return &Sender{queue: q, priority: priority}
+}
+
+// enqueue ставит отправку в очередь и ждет ее результата
+func (q *Queue) enqueue(chat string, priority Priority, send func() (*tb.Message, error)) (*tb.Message, error) {
+	req := &request{chat: chat, priority: priority, send: send, result: make(chan result, 1)}
+
+	q.mu.Lock()
+	if q.stopped {
+		q.mu.Unlock()
+		return nil, ErrQueueStopped
+	}
+	q.queues[priority].PushBack(req)
+	q.mu.Unlock()
+	q.signal()
+
+	res := <-req.result
+	return res.msg, res.err
+}
+
+// signal будит цикл отправки, не блокируясь, если он уже разбужен
+func (q *Queue) signal() {
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+// run выбирает следующее сообщение, которое можно отправить, и отправляет его в отдельной горутине,
+// чтобы задержка ответа Telegram не снижала пропускную способность
+func (q *Queue) run() {
+	defer close(q.done)
+
+	for {
+		q.mu.Lock()
+		req, wait := q.next(time.Now())
+		q.mu.Unlock()
+
+		if req != nil {
+			q.inflight.Add(1)
+			go q.send(req)
+			continue
+		}
+
+		var timeout <-chan time.Time
+		if wait > 0 {
+			timeout = time.After(wait)
+		}
+		select {
+		case <-q.stop:
+			return
+		case <-q.wake:
+		case <-timeout:
+		}
+	}
+}
+
+// next возвращает первое по приоритету сообщение, чат которого готов принять его, и забирает для него маркер.
+// Если отправить сейчас нечего, возвращает, сколько ждать до следующей проверки, или 0, если очередь пуста.
+// Вызывается под q.mu.
+func (q *Queue) next(now time.Time) (*request, time.Duration) {
+	if now.Before(q.pausedUntil) {
+		return nil, q.pausedUntil.Sub(now)
+	}
+
+	q.tokens = min(q.rate, q.tokens+now.Sub(q.refilled).Seconds()*q.rate)
+	q.refilled = now
+
+	var wait time.Duration
+	for _, queue := range q.queues {
+		for e := queue.Front(); e != nil; e = e.Next() {
+			req := e.Value.(*request)
+			ready := q.chatNext[req.chat]
+			if ready.After(now) {
+				if d := ready.Sub(now); wait == 0 || d < wait {
+					wait = d
+				}
+				continue
+			}
+			if q.tokens < 1 {
+				return nil, time.Duration((1 - q.tokens) / q.rate * float64(time.Second))
+			}
+			queue.Remove(e)
+			q.tokens--
+			q.chatNext[req.chat] = now.Add(q.chatInterval)
+			q.sweep(now)
+			return req, 0
+		}
+	}
+	return nil, wait
+}
+
+// sweep удаляет отметки чатов, в которые уже можно отправлять, когда их накопилось много. Вызывается под q.mu.
+func (q *Queue) sweep(now time.Time) {
+	if len(q.chatNext) < 1024 {
+		return
+	}
+	for chat, ready := range q.chatNext {
+		if !ready.After(now) {
+			delete(q.chatNext, chat)
+		}
+	}
+}
+
+// send отправляет сообщение. После ответа 429 очередь приостанавливается на retry_after,
+// а сообщение возвращается в начало очереди, пока не кончатся повторы.
+func (q *Queue) send(req *request) {
+	defer q.inflight.Done()
+
+	msg, err := req.send()
+	retryAfter, flood := floodWait(err)
+	if !flood {
+		if err == nil {
+			q.sent.Add(1)
+		}
+		req.result <- result{msg: msg, err: err}
+		return
+	}
+
+	q.throttled.Add(1)
+	q.mu.Lock()
+	if until := time.Now().Add(retryAfter); until.After(q.pausedUntil) {
+		q.pausedUntil = until
+	}
+	if q.stopped || req.retries >= q.floodRetries {
+		q.mu.Unlock()
+		req.result <- result{err: err}
+		return
+	}
+	req.retries++
+	q.queues[req.priority].PushFront(req)
+	q.mu.Unlock()
+	q.signal()
+}
+
+// floodWait сообщает, что Telegram ответил 429, и сколько он просит подождать
+func floodWait(err error) (time.Duration, bool) {
+	var flood tb.FloodError
+	if errors.As(err, &flood) {
+		if flood.RetryAfter > 0 {
+			return time.Duration(flood.RetryAfter) * time.Second, true
+		}
+		return floodDelay, true
+	}
+	var apiErr *tb.APIError
+	if errors.As(err, &apiErr) && apiErr.Code == 429 {
+		return floodDelay, true
+	}
+	return 0, false
+}
+
+// Sender отправляет сообщения через очередь с заданным приоритетом.
+// Методы повторяют методы *tb.Bot и блокируются до отправки сообщения.
+type Sender struct {
+	queue    *Queue
+	priority Priority
+}
+
+// Send отправляет сообщение получателю to
+func (s *Sender) Send(to tb.Recipient, what interface{}, options ...interface{}) (*tb.Message, error) {
+	return s.queue.enqueue(to.Recipient(), s.priority, func() (*tb.Message, error) {
+		return s.queue.bot.Send(to, what, options...)
+	})
+}
+
+// Edit изменяет отправленное ранее сообщение
+func (s *Sender) Edit(msg tb.Editable, what interface{}, options ...interface{}) (*tb.Message, error) {
+	_, chatID := msg.MessageSig()
+	return s.queue.enqueue(strconv.FormatInt(chatID, 10), s.priority, func() (*tb.Message, error) {
+		return s.queue.bot.Edit(msg, what, options...)
+	})
+}
+
+// Respond отвечает на нажатие кнопки. Ответы на нажатия не считаются сообщениями в чат и идут в обход очереди.
+func (s *Sender) Respond(c *tb.Callback, resp ...*tb.CallbackResponse) error {
+	return s.queue.bot.Respond(c, resp...)
+}
@@ -0,0 +1,150 @@
+package telegram
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	tb "gopkg.in/tucnak/telebot.v2"
+)
+
+// push ставит в очередь отправку в чат chat, не дожидаясь результата
+func push(q *Queue, chat string, priority Priority, send func() (*tb.Message, error)) *request {
+	req := &request{chat: chat, priority: priority, send: send, result: make(chan result, 1)}
+	q.queues[priority].PushBack(req)
+	return req
+}
+
+func sendOK() (*tb.Message, error) {
+	return &tb.Message{}, nil
+}
+
+func TestQueueNextPriority(t *testing.T) {
+	q := NewQueue(nil)
+	now := time.Now()
+	scheduled := push(q, "1", PriorityScheduled, sendOK)
+	interactive := push(q, "2", PriorityInteractive, sendOK)
+
+	if req, _ := q.next(now); req != interactive {
+		t.Fatalf("первым должен уйти ответ пользователю")
+	}
+	if req, _ := q.next(now); req != scheduled {
+		t.Fatalf("вторым должно уйти уведомление по расписанию")
+	}
+	if req, wait := q.next(now); req != nil || wait != 0 {
+		t.Fatalf("пустая очередь вернула %v, ожидание %s", req, wait)
+	}
+}
+
+func TestQueueNextChatInterval(t *testing.T) {
+	q := NewQueue(nil, WithChatInterval(time.Second))
+	now := time.Now()
+	first := push(q, "1", PriorityInteractive, sendOK)
+	second := push(q, "1", PriorityInteractive, sendOK)
+	other := push(q, "2", PriorityScheduled, sendOK)
+
+	if req, _ := q.next(now); req != first {
+		t.Fatalf("первым должно уйти первое сообщение в чат")
+	}
+	// Второе сообщение в тот же чат ждет, но не задерживает сообщения в другие чаты, даже менее срочные
+	if req, _ := q.next(now); req != other {
+		t.Fatalf("сообщение в другой чат должно уйти, пока первый чат ждет")
+	}
+	req, wait := q.next(now)
+	if req != nil || wait != time.Second {
+		t.Fatalf("второе сообщение в чат должно ждать %s, получено %v и %s", time.Second, req, wait)
+	}
+	if req, _ := q.next(now.Add(wait)); req != second {
+		t.Fatalf("второе сообщение в чат должно уйти через промежуток между сообщениями")
+	}
+}
+
+func TestQueueNextGlobalRate(t *testing.T) {
+	q := NewQueue(nil, WithGlobalRate(2), WithChatInterval(0))
+	now := q.refilled
+	for _, chat := range []string{"1", "2", "3"} {
+		push(q, chat, PriorityScheduled, sendOK)
+	}
+
+	for i := range 2 {
+		if req, _ := q.next(now); req == nil {
+			t.Fatalf("сообщение %d должно уйти, пока в корзине есть маркеры", i+1)
+		}
+	}
+	req, wait := q.next(now)
+	if req != nil || wait != 500*time.Millisecond {
+		t.Fatalf("третье сообщение должно ждать маркер 500ms, получено %v и %s", req, wait)
+	}
+	if req, _ := q.next(now.Add(wait)); req == nil {
+		t.Fatalf("третье сообщение должно уйти, когда появится маркер")
+	}
+}
+
+func TestQueueSendFloodRequeue(t *testing.T) {
+	q := NewQueue(nil, WithFloodRetries(1), WithChatInterval(0))
+	flood := tb.FloodError{APIError: &tb.APIError{Code: 429, Description: "Too Many Requests"}, RetryAfter: 2}
+	calls := 0
+	req := push(q, "1", PriorityScheduled, func() (*tb.Message, error) {
+		calls++
+		return nil, flood
+	})
+
+	now := time.Now()
+	if got, _ := q.next(now); got != req {
+		t.Fatalf("сообщение должно уйти")
+	}
+	q.inflight.Add(1)
+	q.send(req)
+
+	if q.queues[PriorityScheduled].Front().Value != req {
+		t.Fatalf("после 429 сообщение должно вернуться в начало очереди")
+	}
+	if q.Stats().Throttled != 1 {
+		t.Fatalf("ответ 429 должен попасть в счетчик")
+	}
+	if got, wait := q.next(now); got != nil || wait < time.Second {
+		t.Fatalf("после 429 очередь должна ждать retry_after, получено %v и %s", got, wait)
+	}
+
+	got, _ := q.next(q.pausedUntil)
+	if got != req {
+		t.Fatalf("после паузы сообщение должно уйти повторно")
+	}
+	q.inflight.Add(1)
+	q.send(got)
+
+	res := <-req.result
+	var floodErr tb.FloodError
+	if !errors.As(res.err, &floodErr) || calls != 2 {
+		t.Fatalf("после исчерпания повторов должна вернуться ошибка 429, получено %v после %d попыток", res.err, calls)
+	}
+	if q.queues[PriorityScheduled].Len() != 0 {
+		t.Fatalf("сообщение без повторов не должно остаться в очереди")
+	}
+}
+
+func TestQueueStartStop(t *testing.T) {
+	q := NewQueue(nil, WithGlobalRate(1000), WithChatInterval(0))
+	q.Start()
+
+	var wg sync.WaitGroup
+	for _, chat := range []string{"1", "2", "3"} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := q.enqueue(chat, PriorityInteractive, sendOK); err != nil {
+				t.Errorf("отправка в чат %s: %v", chat, err)
+			}
+		}()
+	}
+	wg.Wait()
+	if sent := q.Stats().Sent; sent != 3 {
+		t.Fatalf("должно быть отправлено 3 сообщения, отправлено %d", sent)
+	}
+
+	q.Stop()
+	if _, err := q.enqueue("1", PriorityInteractive, sendOK); !errors.Is(err, ErrQueueStopped) {
+		t.Fatalf("после остановки очередь должна возвращать ErrQueueStopped, получено %v", err)
+	}
+}
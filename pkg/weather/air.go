@@ -0,0 +1,56 @@
+package weather
+
+import "context"
+
+// AirQuality — качество воздуха в месте
+type AirQuality struct {
+	City string  // Название места
+	AQI  int     // Индекс качества воздуха OpenWeatherMap: 1 — хорошее, 5 — очень плохое
+	PM25 float64 // Мелкодисперсные частицы PM2.5, мкг/м³
+	PM10 float64 // Взвешенные частицы PM10, мкг/м³
+	O3   float64 // Озон, мкг/м³
+	NO2  float64 // Диоксид азота, мкг/м³
+}
+
+// AirQualityProvider — источник данных о качестве воздуха
+type AirQualityProvider interface {
+	// AirQuality возвращает текущее качество воздуха по координатам
+	AirQuality(ctx context.Context, coords Coords) (*AirQuality, error)
+}
+
+// Структура для ответа от OpenWeatherMap Air Pollution API
+type airPollutionResponse struct {
+	List []struct {
+		Main struct {
+			AQI int `json:"aqi"`
+		} `json:"main"`
+		Components struct {
+			PM25 float64 `json:"pm2_5"`
+			PM10 float64 `json:"pm10"`
+			O3   float64 `json:"o3"`
+			NO2  float64 `json:"no2"`
+		} `json:"components"`
+	} `json:"list"`
+}
+
+var _ AirQualityProvider = (*Client)(nil)
+
+// AirQuality получает текущее качество воздуха по координатам
+func (c *Client) AirQuality(ctx context.Context, coords Coords) (*AirQuality, error) {
+	var data airPollutionResponse
+	if err := c.get(ctx, "/data/2.5/air_pollution", coordsQuery(coords.Lat, coords.Lon), &data); err != nil {
+		return nil, err
+	}
+	if len(data.List) == 0 {
+		return nil, &APIError{Message: "пустой ответ о качестве воздуха", Err: ErrDecode}
+	}
+
+	item := data.List[0]
+	return &AirQuality{
+		AQI:  item.Main.AQI,
+		PM25: item.Components.PM25,
+		PM10: item.Components.PM10,
+		O3:   item.Components.O3,
+		NO2:  item.Components.NO2,
+	}, nil
+}
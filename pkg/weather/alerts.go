@@ -0,0 +1,135 @@
+package weather
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Alert — официальное предупреждение о неблагоприятной погоде
+type Alert struct {
+	ID          string    // Идентификатор, одинаковый для одного и того же предупреждения в разных ответах
+	Sender      string    // Источник предупреждения
+	Event       string    // Название явления
+	Start       time.Time // Начало действия по местному времени
+	End         time.Time // Окончание действия по местному времени
+	Description string    // Текст предупреждения
+}
+
+// AlertProvider — источник предупреждений о неблагоприятной погоде
+type AlertProvider interface {
+	// Alerts возвращает действующие предупреждения для координат с текстом на языке lang, если провайдер его поддерживает
+	Alerts(ctx context.Context, coords Coords, lang string) ([]Alert, error)
+}
+
+// alertIDLang — язык, на котором запрашиваются предупреждения для построения идентификаторов
+const alertIDLang = "en"
+
+// Структура для ответа от OpenWeatherMap One Call API, только предупреждения
+type oneCallAlertsResponse struct {
+	TimezoneOffset int            `json:"timezone_offset"`
+	Alerts         []oneCallAlert `json:"alerts"`
+}
+
+type oneCallAlert struct {
+	SenderName  string   `json:"sender_name"`
+	Event       string   `json:"event"`
+	Start       int64    `json:"start"`
+	End         int64    `json:"end"`
+	Description string   `json:"description"`
+	Tags        []string `json:"tags"`
+}
+
+var _ AlertProvider = (*Client)(nil)
+
+// Alerts получает действующие предупреждения для координат через One Call API.
+// Если lang отличается от alertIDLang, предупреждения запрашиваются еще и на alertIDLang,
+// чтобы идентификаторы не зависели от языка пользователя.
+func (c *Client) Alerts(ctx context.Context, coords Coords, lang string) ([]Alert, error) {
+	lang = langOrDefault(lang)
+	data, err := c.oneCallAlerts(ctx, coords, lang)
+	if err != nil {
+		return nil, err
+	}
+	fixed := data
+	if lang != alertIDLang && len(data.Alerts) > 0 {
+		if fixed, err = c.oneCallAlerts(ctx, coords, alertIDLang); err != nil {
+			return nil, err
+		}
+	}
+	ids := alertIDs(data.Alerts, fixed.Alerts)
+
+	loc := time.FixedZone("", data.TimezoneOffset)
+	alerts := make([]Alert, 0, len(data.Alerts))
+	for i, item := range data.Alerts {
+		alerts = append(alerts, Alert{
+			ID:          ids[i],
+			Sender:      item.SenderName,
+			Event:       item.Event,
+			Start:       time.Unix(item.Start, 0).In(loc),
+			End:         time.Unix(item.End, 0).In(loc),
+			Description: item.Description,
+		})
+	}
+	return alerts, nil
+}
+
+func (c *Client) oneCallAlerts(ctx context.Context, coords Coords, lang string) (oneCallAlertsResponse, error) {
+	query := coordsQuery(coords.Lat, coords.Lon)
+	query.Set("exclude", "current,minutely,hourly,daily")
+	query.Set("lang", lang)
+
+	var data oneCallAlertsResponse
+	err := c.get(ctx, "/data/3.0/onecall", query, &data)
+	return data, err
+}
+
+// alertIDs строит идентификаторы предупреждений: OpenWeatherMap не возвращает собственных.
+// Название и текст предупреждения переводятся на язык запроса, поэтому они берутся из ответа
+// на alertIDLang. Предупреждения из двух ответов сопоставляются по источнику, типам явлений
+// и времени действия, а одинаковые по этим полям — по порядку в ответе.
+func alertIDs(alerts, fixed []oneCallAlert) []string {
+	matches := make(map[string][]oneCallAlert)
+	for _, item := range fixed {
+		key := alertKey(item)
+		matches[key] = append(matches[key], item)
+	}
+
+	ids := make([]string, len(alerts))
+	for i, item := range alerts {
+		key := alertKey(item)
+		// Без пары в ответе на alertIDLang идентификатор строится по тексту на языке пользователя
+		if candidates := matches[key]; len(candidates) > 0 {
+			item, matches[key] = candidates[0], candidates[1:]
+		}
+		ids[i] = alertID(key, item.Event, item.Description)
+	}
+	return ids
+}
+
+// alertKey возвращает поля предупреждения, которые не зависят от языка запроса
+func alertKey(item oneCallAlert) string {
+	tags := slices.Clone(item.Tags)
+	slices.Sort(tags)
+	return strings.Join([]string{
+		item.SenderName,
+		strings.Join(tags, ","),
+		strconv.FormatInt(item.Start, 10),
+		strconv.FormatInt(item.End, 10),
+	}, "\x00")
+}
+
+// alertID строит идентификатор по независимым от языка полям и названию и тексту на alertIDLang
+func alertID(key, event, description string) string {
+	h := sha1.New()
+	h.Write([]byte(key))
+	h.Write([]byte{0})
+	h.Write([]byte(event))
+	h.Write([]byte{0})
+	h.Write([]byte(description))
+	return hex.EncodeToString(h.Sum(nil))
+}
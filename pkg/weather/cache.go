@@ -0,0 +1,159 @@
+package weather
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// cacheFetchTimeout ограничивает запрос к провайдеру, общий для всех ожидающих его ответа
+const cacheFetchTimeout = time.Minute
+
+// Cache — провайдер, кэширующий ответы вложенного провайдера на время ttl.
+// Одновременные одинаковые запросы объединяются в один запрос к провайдеру.
+type Cache struct {
+	provider Provider
+	ttl      time.Duration
+
+	mu        sync.Mutex
+	entries   map[string]cacheEntry
+	calls     map[string]*cacheCall
+	lastSweep time.Time
+
+	hits      atomic.Int64
+	misses    atomic.Int64
+	coalesced atomic.Int64
+}
+
+// CacheStats — счетчики обращений к кэшу
+type CacheStats struct {
+	Hits      int64 // Ответ взят из кэша
+	Misses    int64 // Выполнен запрос к провайдеру
+	Coalesced int64 // Запрос дождался ответа уже выполняющегося одинакового запроса
+}
+
+type cacheEntry struct {
+	value   any
+	expires time.Time
+}
+
+type cacheCall struct {
+	done  chan struct{}
+	value any
+	err   error
+}
+
+var _ Provider = (*Cache)(nil)
+
+// NewCache создает кэширующую обертку над provider с временем жизни записей ttl
+func NewCache(provider Provider, ttl time.Duration) *Cache {
+	return &Cache{
+		provider: provider,
+		ttl:      ttl,
+		entries:  make(map[string]cacheEntry),
+		calls:    make(map[string]*cacheCall),
+	}
+}
+
+// Name возвращает название вложенного провайдера
+func (c *Cache) Name() string {
+	return "cache(" + c.provider.Name() + ")"
+}
+
+// Stats возвращает текущие значения счетчиков
+func (c *Cache) Stats() CacheStats {
+	return CacheStats{
+		Hits:      c.hits.Load(),
+		Misses:    c.misses.Load(),
+		Coalesced: c.coalesced.Load(),
+	}
+}
+
+// Current получает текущую погоду из кэша или у провайдера
+func (c *Cache) Current(ctx context.Context, place Place) (*Conditions, error) {
+	value, err := c.do(ctx, "current:"+langOrDefault(place.Lang)+":"+place.Key(), func(ctx context.Context) (any, error) {
+		return c.provider.Current(ctx, place)
+	})
+	if err != nil {
+		return nil, err
+	}
+	// Возвращаем копию, чтобы вызывающий код не мог изменить закэшированное значение
+	conditions := *value.(*Conditions)
+	return &conditions, nil
+}
+
+// Forecast получает прогноз из кэша или у провайдера
+func (c *Cache) Forecast(ctx context.Context, place Place) (*Forecast, error) {
+	value, err := c.do(ctx, "forecast:"+langOrDefault(place.Lang)+":"+place.Key(), func(ctx context.Context) (any, error) {
+		return c.provider.Forecast(ctx, place)
+	})
+	if err != nil {
+		return nil, err
+	}
+	cached := value.(*Forecast)
+	forecast := *cached
+	forecast.Days = append([]DailyForecast(nil), cached.Days...)
+	return &forecast, nil
+}
+
+// do возвращает значение по ключу из кэша, а при его отсутствии вызывает fetch.
+// Пока fetch выполняется, все запросы с тем же ключом, включая первый, ждут его результата
+// или отмены своего ctx. Отмена ctx одного запроса не прерывает fetch для остальных.
+func (c *Cache) do(ctx context.Context, key string, fetch func(ctx context.Context) (any, error)) (any, error) {
+	c.mu.Lock()
+	if entry, ok := c.entries[key]; ok && time.Now().Before(entry.expires) {
+		c.mu.Unlock()
+		c.hits.Add(1)
+		return entry.value, nil
+	}
+	call, ok := c.calls[key]
+	if ok {
+		c.coalesced.Add(1)
+	} else {
+		call = &cacheCall{done: make(chan struct{})}
+		c.calls[key] = call
+		c.misses.Add(1)
+		go c.fetch(ctx, key, call, fetch)
+	}
+	c.mu.Unlock()
+
+	select {
+	case <-call.done:
+		return call.value, call.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// fetch выполняет запрос к провайдеру для call и сохраняет ответ в кэш. Запрос сохраняет значения ctx,
+// но не его отмену: ответа могут ждать и другие запросы, поэтому он ограничен только cacheFetchTimeout.
+func (c *Cache) fetch(ctx context.Context, key string, call *cacheCall, fetch func(ctx context.Context) (any, error)) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), cacheFetchTimeout)
+	defer cancel()
+	call.value, call.err = fetch(ctx)
+
+	now := time.Now()
+	c.mu.Lock()
+	delete(c.calls, key)
+	if call.err == nil {
+		c.entries[key] = cacheEntry{value: call.value, expires: now.Add(c.ttl)}
+	}
+	c.sweep(now)
+	c.mu.Unlock()
+
+	close(call.done)
+}
+
+// sweep удаляет устаревшие записи не чаще одного раза за ttl. Вызывается под c.mu.
+func (c *Cache) sweep(now time.Time) {
+	if now.Sub(c.lastSweep) < c.ttl {
+		return
+	}
+	c.lastSweep = now
+	for key, entry := range c.entries {
+		if !now.Before(entry.expires) {
+			delete(c.entries, key)
+		}
+	}
+}
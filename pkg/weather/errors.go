@@ -0,0 +1,55 @@
+package weather
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+var (
+	ErrCityNotFound  = errors.New("city not found")
+	ErrInvalidAPIKey = errors.New("invalid api key")
+	ErrQuotaExceeded = errors.New("quota exceeded")
+	ErrUnavailable   = errors.New("weather service unavailable")
+	ErrDecode        = errors.New("failed to decode response")
+)
+
+// APIError — ошибка ответа сервиса погоды.
+// Сопоставляется с одной из ошибок Err* через errors.Is.
+type APIError struct {
+	StatusCode int    // HTTP-статус, 0 для сетевых ошибок и ошибок декодирования
+	Message    string // Описание ошибки
+	Err        error  // Одна из ошибок Err*
+	Cause      error  // Исходная ошибка, если есть
+}
+
+func (e *APIError) Error() string {
+	if e.Cause != nil {
+		return fmt.Sprintf("%s: %v", e.Message, e.Cause)
+	}
+	return e.Message
+}
+
+func (e *APIError) Unwrap() []error {
+	if e.Cause != nil {
+		return []error{e.Err, e.Cause}
+	}
+	return []error{e.Err}
+}
+
+// statusError возвращает ошибку, соответствующую HTTP-статусу ответа
+func statusError(statusCode int, status string) *APIError {
+	err := &APIError{StatusCode: statusCode}
+	switch {
+	case statusCode == http.StatusNotFound:
+		err.Err, err.Message = ErrCityNotFound, "город не найден"
+	case statusCode == http.StatusUnauthorized:
+		err.Err, err.Message = ErrInvalidAPIKey, "неверный API-ключ"
+	case statusCode == http.StatusTooManyRequests:
+		err.Err, err.Message = ErrQuotaExceeded, "превышен лимит запросов"
+	default:
+		err.Err, err.Message = ErrUnavailable, "сервис недоступен"
+	}
+	err.Message += ", статус: " + status
+	return err
+}
@@ -0,0 +1,77 @@
+package weather
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// Failover опрашивает провайдеров по очереди и возвращает первый успешный ответ
+type Failover struct {
+	providers []Provider
+}
+
+var _ Provider = (*Failover)(nil)
+
+// NewFailover создает провайдера с переключением между providers в указанном порядке
+func NewFailover(providers ...Provider) *Failover {
+	return &Failover{providers: providers}
+}
+
+// Name возвращает названия провайдеров в порядке опроса
+func (f *Failover) Name() string {
+	names := make([]string, 0, len(f.providers))
+	for _, p := range f.providers {
+		names = append(names, p.Name())
+	}
+	return "failover(" + strings.Join(names, ",") + ")"
+}
+
+// Current получает текущую погоду у первого доступного провайдера
+func (f *Failover) Current(ctx context.Context, place Place) (*Conditions, error) {
+	return try(ctx, f.providers, func(p Provider) (*Conditions, error) {
+		return p.Current(ctx, place)
+	})
+}
+
+// Forecast получает прогноз у первого доступного провайдера
+func (f *Failover) Forecast(ctx context.Context, place Place) (*Forecast, error) {
+	return try(ctx, f.providers, func(p Provider) (*Forecast, error) {
+		return p.Forecast(ctx, place)
+	})
+}
+
+// try опрашивает провайдеров по очереди. Ошибка ErrCityNotFound возвращается, только если город не нашел
+// ни один провайдер: если кто-то из них был недоступен, город мог найтись у него, и возвращаются ошибки доступности.
+func try[T any](ctx context.Context, providers []Provider, call func(p Provider) (*T, error)) (*T, error) {
+	if len(providers) == 0 {
+		return nil, errors.New("не настроен ни один провайдер погоды")
+	}
+
+	var errs, unavailable []error
+	for _, p := range providers {
+		res, err := call(p)
+		if err == nil {
+			return res, nil
+		}
+		log.Printf("Провайдер погоды %s недоступен: %v", p.Name(), err)
+		err = fmt.Errorf("%s: %w", p.Name(), err)
+		errs = append(errs, err)
+		if !errors.Is(err, ErrCityNotFound) {
+			unavailable = append(unavailable, err)
+		}
+		// Если запрос отменен, опрашивать следующих провайдеров бессмысленно
+		if ctx.Err() != nil {
+			if len(unavailable) == 0 {
+				unavailable = append(unavailable, ctx.Err())
+			}
+			break
+		}
+	}
+	if len(unavailable) > 0 {
+		return nil, errors.Join(unavailable...)
+	}
+	return nil, errors.Join(errs...)
+}
@@ -0,0 +1,112 @@
+package weather
+
+import (
+	"context"
+	"net/url"
+	"time"
+)
+
+// Структура для ответа от OpenWeatherMap на запрос прогноза (5 дней / 3 часа)
+type ForecastResponse struct {
+	List []struct {
+		Dt   int64 `json:"dt"` // Время прогноза, unix UTC
+		Main struct {
+			Temp    float64 `json:"temp"`     // Температура
+			TempMin float64 `json:"temp_min"` // Минимальная температура
+			TempMax float64 `json:"temp_max"` // Максимальная температура
+		} `json:"main"`
+		Weather []struct {
+			Description string `json:"description"` // Описание погоды
+		} `json:"weather"`
+		Pop float64 `json:"pop"` // Вероятность осадков, 0..1
+	} `json:"list"`
+	City struct {
+		Name     string `json:"name"`     // Название города
+		Timezone int    `json:"timezone"` // Смещение от UTC в секундах
+	} `json:"city"`
+}
+
+// DailyForecast — сводка прогноза за один день
+type DailyForecast struct {
+	Date        time.Time // Дата (полночь по местному времени города)
+	TempMin     float64   // Минимальная температура за день
+	TempMax     float64   // Максимальная температура за день
+	PrecipProb  float64   // Максимальная вероятность осадков за день, 0..1
+	Description string    // Преобладающее описание погоды
+}
+
+// Forecast — прогноз по дням для города
+type Forecast struct {
+	City string
+	Days []DailyForecast
+}
+
+// Forecast получает прогноз погоды на несколько дней для указанного места
+func (c *Client) Forecast(ctx context.Context, place Place) (*Forecast, error) {
+	if place.Coords != nil {
+		return c.GetForecastByCoords(ctx, place.Coords.Lat, place.Coords.Lon, place.Lang)
+	}
+	return c.GetForecast(ctx, place.City, place.Lang)
+}
+
+// GetForecast получает прогноз погоды на несколько дней для указанного города с описаниями на языке lang
+func (c *Client) GetForecast(ctx context.Context, city, lang string) (*Forecast, error) {
+	return c.getForecast(ctx, url.Values{"q": {city}}, lang)
+}
+
+// GetForecastByCoords получает прогноз погоды на несколько дней по координатам с описаниями на языке lang
+func (c *Client) GetForecastByCoords(ctx context.Context, lat, lon float64, lang string) (*Forecast, error) {
+	return c.getForecast(ctx, coordsQuery(lat, lon), lang)
+}
+
+func (c *Client) getForecast(ctx context.Context, query url.Values, lang string) (*Forecast, error) {
+	query.Set("units", "metric")
+	query.Set("lang", langOrDefault(lang))
+
+	var forecastData ForecastResponse
+	if err := c.get(ctx, "/data/2.5/forecast", query, &forecastData); err != nil {
+		return nil, err
+	}
+
+	return &Forecast{
+		City: forecastData.City.Name,
+		Days: groupByDay(&forecastData),
+	}, nil
+}
+
+// groupByDay сворачивает трёхчасовые интервалы в дневные сводки по местному времени города
+func groupByDay(data *ForecastResponse) []DailyForecast {
+	loc := time.FixedZone("", data.City.Timezone)
+
+	var days []DailyForecast
+	var counts []map[string]int
+	for _, item := range data.List {
+		t := time.Unix(item.Dt, 0).In(loc)
+		date := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+
+		if len(days) == 0 || !days[len(days)-1].Date.Equal(date) {
+			days = append(days, DailyForecast{
+				Date:    date,
+				TempMin: item.Main.TempMin,
+				TempMax: item.Main.TempMax,
+			})
+			counts = append(counts, make(map[string]int))
+		}
+
+		day := &days[len(days)-1]
+		day.TempMin = min(day.TempMin, item.Main.TempMin)
+		day.TempMax = max(day.TempMax, item.Main.TempMax)
+		day.PrecipProb = max(day.PrecipProb, item.Pop)
+
+		if len(item.Weather) > 0 {
+			count := counts[len(counts)-1]
+			desc := item.Weather[0].Description
+			count[desc]++
+			if count[desc] > count[day.Description] {
+				day.Description = desc
+			}
+		}
+	}
+
+	return days
+}
@@ -0,0 +1,82 @@
+package weather
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+)
+
+// geocodingLimit — максимальное число кандидатов, возвращаемых геокодером
+const geocodingLimit = 5
+
+// Location — населённый пункт, найденный геокодером
+type Location struct {
+	Name    string  // Название на запрошенном языке, если есть
+	State   string  // Регион
+	Country string  // Код страны
+	Lat     float64 // Широта
+	Lon     float64 // Долгота
+}
+
+// Структура для ответа от OpenWeatherMap Geocoding API
+type geocodingResponse []struct {
+	Name       string            `json:"name"`
+	LocalNames map[string]string `json:"local_names"`
+	Lat        float64           `json:"lat"`
+	Lon        float64           `json:"lon"`
+	Country    string            `json:"country"`
+	State      string            `json:"state"`
+}
+
+// FindLocations ищет населённые пункты по названию, названия возвращаются на языке lang
+func (c *Client) FindLocations(ctx context.Context, query, lang string) ([]Location, error) {
+	var geoData geocodingResponse
+	params := url.Values{
+		"q":     {query},
+		"limit": {strconv.Itoa(geocodingLimit)},
+	}
+	if err := c.get(ctx, "/geo/1.0/direct", params, &geoData); err != nil {
+		return nil, err
+	}
+
+	return geoData.locations(langOrDefault(lang)), nil
+}
+
+// locations преобразует ответ геокодера в список Location с названиями на языке lang
+func (r geocodingResponse) locations(lang string) []Location {
+	locations := make([]Location, 0, len(r))
+	for _, item := range r {
+		name := item.Name
+		if local, ok := item.LocalNames[lang]; ok {
+			name = local
+		}
+		locations = append(locations, Location{
+			Name:    name,
+			State:   item.State,
+			Country: item.Country,
+			Lat:     item.Lat,
+			Lon:     item.Lon,
+		})
+	}
+	return locations
+}
+
+// ReverseGeocode ищет ближайший населённый пункт по координатам.
+// Возвращает nil, если ничего не найдено.
+func (c *Client) ReverseGeocode(ctx context.Context, lat, lon float64, lang string) (*Location, error) {
+	var geoData geocodingResponse
+	params := coordsQuery(lat, lon)
+	params.Set("limit", "1")
+	if err := c.get(ctx, "/geo/1.0/reverse", params, &geoData); err != nil {
+		return nil, err
+	}
+
+	locations := geoData.locations(langOrDefault(lang))
+	if len(locations) == 0 {
+		return nil, nil
+	}
+	location := locations[0]
+	// Сохраняем точные координаты пользователя, а не координаты центра населённого пункта
+	location.Lat, location.Lon = lat, lon
+	return &location, nil
+}
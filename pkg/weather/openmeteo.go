@@ -0,0 +1,249 @@
+package weather
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const (
+	openMeteoURL          = "https://api.open-meteo.com/v1/forecast"
+	openMeteoGeocodingURL = "https://geocoding-api.open-meteo.com/v1/search"
+	openMeteoForecastDays = 5
+	openMeteoTimeLayout   = "2006-01-02T15:04"
+)
+
+// OpenMeteo — провайдер погоды Open-Meteo, не требующий API-ключа
+type OpenMeteo struct {
+	transport *transport
+}
+
+var _ Provider = (*OpenMeteo)(nil)
+
+// NewOpenMeteo создает новый экземпляр клиента для Open-Meteo
+func NewOpenMeteo(opts ...Option) *OpenMeteo {
+	return &OpenMeteo{transport: newTransport(opts...)}
+}
+
+// Name возвращает название провайдера
+func (o *OpenMeteo) Name() string {
+	return "openmeteo"
+}
+
+// Структура для ответа от Open-Meteo на запрос текущей погоды
+type openMeteoCurrentResponse struct {
+	UTCOffset int `json:"utc_offset_seconds"`
+	Current   struct {
+		Temp        float64 `json:"temperature_2m"`
+		FeelsLike   float64 `json:"apparent_temperature"`
+		Humidity    int     `json:"relative_humidity_2m"`
+		Pressure    float64 `json:"pressure_msl"`
+		WindSpeed   float64 `json:"wind_speed_10m"`
+		WindGust    float64 `json:"wind_gusts_10m"`
+		WindDeg     int     `json:"wind_direction_10m"`
+		Clouds      int     `json:"cloud_cover"`
+		Visibility  float64 `json:"visibility"`
+		WeatherCode int     `json:"weather_code"`
+	} `json:"current"`
+	Daily struct {
+		Sunrise []string `json:"sunrise"`
+		Sunset  []string `json:"sunset"`
+	} `json:"daily"`
+}
+
+// Структура для ответа от Open-Meteo на запрос прогноза по дням
+type openMeteoDailyResponse struct {
+	UTCOffset int `json:"utc_offset_seconds"`
+	Daily     struct {
+		Time        []string  `json:"time"`
+		WeatherCode []int     `json:"weather_code"`
+		TempMax     []float64 `json:"temperature_2m_max"`
+		TempMin     []float64 `json:"temperature_2m_min"`
+		PrecipProb  []float64 `json:"precipitation_probability_max"` // В процентах
+	} `json:"daily"`
+}
+
+// Структура для ответа от геокодера Open-Meteo
+type openMeteoGeocodingResponse struct {
+	Results []struct {
+		Name string  `json:"name"`
+		Lat  float64 `json:"latitude"`
+		Lon  float64 `json:"longitude"`
+	} `json:"results"`
+}
+
+// Current получает текущую погоду в указанном месте
+func (o *OpenMeteo) Current(ctx context.Context, place Place) (*Conditions, error) {
+	name, coords, err := o.resolve(ctx, place)
+	if err != nil {
+		return nil, err
+	}
+
+	query := openMeteoQuery(coords)
+	query.Set("current", "temperature_2m,apparent_temperature,relative_humidity_2m,pressure_msl,"+
+		"wind_speed_10m,wind_gusts_10m,wind_direction_10m,cloud_cover,visibility,weather_code")
+	query.Set("daily", "sunrise,sunset")
+	query.Set("forecast_days", "1")
+	query.Set("wind_speed_unit", "ms")
+
+	var data openMeteoCurrentResponse
+	if err := o.transport.getJSON(ctx, openMeteoURL+"?"+query.Encode(), &data); err != nil {
+		return nil, fmt.Errorf("Open-Meteo: %w", err)
+	}
+
+	loc := time.FixedZone("", data.UTCOffset)
+	conditions := &Conditions{
+		City:        name,
+		Condition:   wmoCondition(data.Current.WeatherCode),
+		Description: wmoDescription(data.Current.WeatherCode, place.Lang),
+		Temp:        data.Current.Temp,
+		FeelsLike:   data.Current.FeelsLike,
+		Humidity:    data.Current.Humidity,
+		Pressure:    data.Current.Pressure,
+		WindSpeed:   data.Current.WindSpeed,
+		WindGust:    data.Current.WindGust,
+		WindDeg:     data.Current.WindDeg,
+		Clouds:      data.Current.Clouds,
+		Visibility:  int(data.Current.Visibility),
+		UTCOffset:   data.UTCOffset,
+	}
+	// Open-Meteo возвращает время восхода и заката в местном времени без смещения
+	if len(data.Daily.Sunrise) > 0 && len(data.Daily.Sunset) > 0 {
+		conditions.Sunrise, _ = time.ParseInLocation(openMeteoTimeLayout, data.Daily.Sunrise[0], loc)
+		conditions.Sunset, _ = time.ParseInLocation(openMeteoTimeLayout, data.Daily.Sunset[0], loc)
+	}
+	return conditions, nil
+}
+
+// Forecast получает прогноз погоды на несколько дней для указанного места
+func (o *OpenMeteo) Forecast(ctx context.Context, place Place) (*Forecast, error) {
+	name, coords, err := o.resolve(ctx, place)
+	if err != nil {
+		return nil, err
+	}
+
+	query := openMeteoQuery(coords)
+	query.Set("daily", "weather_code,temperature_2m_max,temperature_2m_min,precipitation_probability_max")
+	query.Set("forecast_days", strconv.Itoa(openMeteoForecastDays))
+
+	var data openMeteoDailyResponse
+	if err := o.transport.getJSON(ctx, openMeteoURL+"?"+query.Encode(), &data); err != nil {
+		return nil, fmt.Errorf("Open-Meteo: %w", err)
+	}
+
+	loc := time.FixedZone("", data.UTCOffset)
+	forecast := &Forecast{City: name}
+	for i, day := range data.Daily.Time {
+		date, err := time.ParseInLocation(time.DateOnly, day, loc)
+		if err != nil {
+			return nil, fmt.Errorf("Open-Meteo: ошибка разбора даты %q: %v", day, err)
+		}
+		forecast.Days = append(forecast.Days, DailyForecast{
+			Date:        date,
+			TempMin:     at(data.Daily.TempMin, i),
+			TempMax:     at(data.Daily.TempMax, i),
+			PrecipProb:  at(data.Daily.PrecipProb, i) / 100,
+			Description: wmoDescription(int(at(data.Daily.WeatherCode, i)), place.Lang),
+		})
+	}
+	return forecast, nil
+}
+
+// resolve возвращает название и координаты места, при необходимости ищет город геокодером Open-Meteo
+func (o *OpenMeteo) resolve(ctx context.Context, place Place) (string, Coords, error) {
+	if place.Coords != nil {
+		return place.City, *place.Coords, nil
+	}
+
+	query := url.Values{
+		"name":     {place.City},
+		"count":    {"1"},
+		"language": {langOrDefault(place.Lang)},
+	}
+	var data openMeteoGeocodingResponse
+	if err := o.transport.getJSON(ctx, openMeteoGeocodingURL+"?"+query.Encode(), &data); err != nil {
+		return "", Coords{}, fmt.Errorf("Open-Meteo: %w", err)
+	}
+	if len(data.Results) == 0 {
+		return "", Coords{}, fmt.Errorf("Open-Meteo: %w", &APIError{
+			Message: fmt.Sprintf("город %q не найден", place.City),
+			Err:     ErrCityNotFound,
+		})
+	}
+
+	res := data.Results[0]
+	return res.Name, Coords{Lat: res.Lat, Lon: res.Lon}, nil
+}
+
+func openMeteoQuery(coords Coords) url.Values {
+	return url.Values{
+		"latitude":  {strconv.FormatFloat(coords.Lat, 'f', -1, 64)},
+		"longitude": {strconv.FormatFloat(coords.Lon, 'f', -1, 64)},
+		"timezone":  {"auto"},
+	}
+}
+
+// at возвращает i-й элемент или ноль, если Open-Meteo вернул массив короче ожидаемого
+func at[T int | float64](values []T, i int) float64 {
+	if i >= len(values) {
+		return 0
+	}
+	return float64(values[i])
+}
+
+// wmoCondition возвращает класс погодных условий по коду WMO
+func wmoCondition(code int) Condition {
+	switch {
+	case code == 0:
+		return ConditionClear
+	case code == 1 || code == 2:
+		return ConditionPartlyCloudy
+	case code == 3:
+		return ConditionCloudy
+	case code == 45 || code == 48:
+		return ConditionFog
+	case code >= 51 && code <= 57:
+		return ConditionDrizzle
+	case code >= 61 && code <= 67, code >= 80 && code <= 82:
+		return ConditionRain
+	case code >= 71 && code <= 77, code == 85 || code == 86:
+		return ConditionSnow
+	case code >= 95 && code <= 99:
+		return ConditionThunderstorm
+	default:
+		return ConditionUnknown
+	}
+}
+
+// wmoDescriptions — описания погоды по кодам WMO, которые использует Open-Meteo
+var wmoDescriptions = map[string]map[int]string{
+	"ru": {
+		0: "ясно", 1: "преимущественно ясно", 2: "переменная облачность", 3: "пасмурно",
+		45: "туман", 48: "туман",
+		51: "морось", 53: "морось", 55: "морось", 56: "ледяная морось", 57: "ледяная морось",
+		61: "небольшой дождь", 63: "дождь", 65: "сильный дождь", 66: "ледяной дождь", 67: "ледяной дождь",
+		71: "небольшой снег", 73: "снег", 75: "сильный снег", 77: "снежная крупа",
+		80: "ливень", 81: "ливень", 82: "ливень", 85: "снегопад", 86: "снегопад",
+		95: "гроза", 96: "гроза с градом", 99: "гроза с градом",
+	},
+	"en": {
+		0: "clear sky", 1: "mainly clear", 2: "partly cloudy", 3: "overcast",
+		45: "fog", 48: "fog",
+		51: "drizzle", 53: "drizzle", 55: "drizzle", 56: "freezing drizzle", 57: "freezing drizzle",
+		61: "light rain", 63: "rain", 65: "heavy rain", 66: "freezing rain", 67: "freezing rain",
+		71: "light snow", 73: "snow", 75: "heavy snow", 77: "snow grains",
+		80: "rain showers", 81: "rain showers", 82: "rain showers", 85: "snow showers", 86: "snow showers",
+		95: "thunderstorm", 96: "thunderstorm with hail", 99: "thunderstorm with hail",
+	},
+}
+
+// wmoDescription возвращает описание погоды по коду WMO на языке lang
+func wmoDescription(code int, lang string) string {
+	descriptions, ok := wmoDescriptions[lang]
+	if !ok {
+		descriptions = wmoDescriptions[defaultLang]
+	}
+	return descriptions[code]
+}
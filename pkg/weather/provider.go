@@ -0,0 +1,93 @@
+package weather
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Provider — источник данных о погоде, не зависящий от конкретного API
+type Provider interface {
+	// Name возвращает название провайдера для логов
+	Name() string
+	// Current возвращает текущую погоду в указанном месте
+	Current(ctx context.Context, place Place) (*Conditions, error)
+	// Forecast возвращает прогноз по дням для указанного места
+	Forecast(ctx context.Context, place Place) (*Forecast, error)
+}
+
+// Geocoder — поиск населённых пунктов по названию и по координатам
+type Geocoder interface {
+	// FindLocations ищет населённые пункты по названию, названия возвращаются на языке lang
+	FindLocations(ctx context.Context, query, lang string) ([]Location, error)
+	// ReverseGeocode ищет ближайший населённый пункт по координатам, nil если ничего не найдено
+	ReverseGeocode(ctx context.Context, lat, lon float64, lang string) (*Location, error)
+}
+
+// Coords — географические координаты
+type Coords struct {
+	Lat float64
+	Lon float64
+}
+
+// Place — место, для которого запрашивается погода.
+// Если заданы координаты, провайдер использует их, иначе ищет город по названию.
+type Place struct {
+	City   string
+	Coords *Coords
+	Lang   string // Язык описаний погоды, например "ru" или "en"; пустой — язык по умолчанию
+}
+
+// defaultLang — язык описаний погоды, если он не указан
+const defaultLang = "ru"
+
+func langOrDefault(lang string) string {
+	if lang == "" {
+		return defaultLang
+	}
+	return lang
+}
+
+// Key возвращает нормализованный ключ места: координаты округляются примерно до километра,
+// название города приводится к нижнему регистру
+func (p Place) Key() string {
+	if p.Coords != nil {
+		return fmt.Sprintf("%.2f,%.2f", p.Coords.Lat, p.Coords.Lon)
+	}
+	return strings.ToLower(strings.Join(strings.Fields(p.City), " "))
+}
+
+// Conditions — текущая погода в месте
+type Conditions struct {
+	City        string    // Название места
+	Condition   Condition // Класс погодных условий
+	Description string    // Описание погоды
+	Temp        float64   // Температура, °C
+	FeelsLike   float64   // Ощущаемая температура, °C
+	Humidity    int       // Влажность, %
+	Pressure    float64   // Давление на уровне моря, гПа
+	WindSpeed   float64   // Скорость ветра, м/с
+	WindGust    float64   // Порывы ветра, м/с, 0 если нет данных
+	WindDeg     int       // Направление, откуда дует ветер, градусы
+	Clouds      int       // Облачность, %
+	Visibility  int       // Видимость, м
+	Sunrise     time.Time // Восход по местному времени, нулевое значение если нет данных
+	Sunset      time.Time // Закат по местному времени, нулевое значение если нет данных
+	UTCOffset   int       // Смещение местного времени от UTC, секунды
+}
+
+// Condition — класс погодных условий, не зависящий от провайдера
+type Condition string
+
+const (
+	ConditionUnknown      Condition = ""
+	ConditionClear        Condition = "clear"         // Ясно
+	ConditionPartlyCloudy Condition = "partly_cloudy" // Переменная облачность
+	ConditionCloudy       Condition = "cloudy"        // Облачно, пасмурно
+	ConditionFog          Condition = "fog"           // Туман, дымка
+	ConditionDrizzle      Condition = "drizzle"       // Морось
+	ConditionRain         Condition = "rain"          // Дождь
+	ConditionSnow         Condition = "snow"          // Снег
+	ConditionThunderstorm Condition = "thunderstorm"  // Гроза
+)
@@ -0,0 +1,32 @@
+package weather
+
+import (
+	"context"
+	"fmt"
+)
+
+// TimezoneProvider — определение часового пояса по координатам
+type TimezoneProvider interface {
+	// Timezone возвращает название часового пояса IANA, например "Europe/Moscow"
+	Timezone(ctx context.Context, coords Coords) (string, error)
+}
+
+var _ TimezoneProvider = (*OpenMeteo)(nil)
+
+// Структура для ответа от Open-Meteo без погодных данных, только сведения о месте
+type openMeteoTimezoneResponse struct {
+	Timezone string `json:"timezone"`
+}
+
+// Timezone определяет часовой пояс по координатам. Open-Meteo возвращает его с любым прогнозом
+// при timezone=auto, поэтому запрашивается прогноз без погодных переменных.
+func (o *OpenMeteo) Timezone(ctx context.Context, coords Coords) (string, error) {
+	var data openMeteoTimezoneResponse
+	if err := o.transport.getJSON(ctx, openMeteoURL+"?"+openMeteoQuery(coords).Encode(), &data); err != nil {
+		return "", fmt.Errorf("Open-Meteo: %w", err)
+	}
+	if data.Timezone == "" {
+		return "", fmt.Errorf("Open-Meteo: %w", &APIError{Message: "часовой пояс не определен", Err: ErrDecode})
+	}
+	return data.Timezone, nil
+}
@@ -0,0 +1,220 @@
+package weather
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	defaultTimeout    = 10 * time.Second
+	defaultMaxRetries = 3
+	defaultBaseDelay  = 500 * time.Millisecond
+	defaultMaxDelay   = 30 * time.Second
+)
+
+// Option настраивает HTTP-транспорт клиента погоды
+type Option func(t *transport)
+
+// WithHTTPClient задает HTTP-клиент для запросов
+func WithHTTPClient(client *http.Client) Option {
+	return func(t *transport) {
+		t.client = client
+	}
+}
+
+// WithTimeout задает таймаут одной попытки запроса
+func WithTimeout(timeout time.Duration) Option {
+	return func(t *transport) {
+		t.timeout = timeout
+	}
+}
+
+// WithRetries задает число повторов при сетевых ошибках, 5xx и 429,
+// начальную и максимальную задержку между попытками
+func WithRetries(maxRetries int, baseDelay, maxDelay time.Duration) Option {
+	return func(t *transport) {
+		t.maxRetries = maxRetries
+		t.baseDelay = baseDelay
+		t.maxDelay = maxDelay
+	}
+}
+
+// WithRateLimit ограничивает частоту запросов: не более perMinute в минуту с допустимым всплеском burst.
+// При perMinute <= 0 ограничение снимается.
+func WithRateLimit(perMinute, burst int) Option {
+	return func(t *transport) {
+		if perMinute <= 0 {
+			t.limiter = nil
+			return
+		}
+		t.limiter = newRateLimiter(float64(perMinute)/60, max(burst, 1))
+	}
+}
+
+// transport выполняет HTTP-запросы с таймаутами, повторами и ограничением частоты
+type transport struct {
+	client     *http.Client
+	timeout    time.Duration
+	maxRetries int
+	baseDelay  time.Duration
+	maxDelay   time.Duration
+	limiter    *rateLimiter
+}
+
+func newTransport(opts ...Option) *transport {
+	t := &transport{
+		client:     http.DefaultClient,
+		timeout:    defaultTimeout,
+		maxRetries: defaultMaxRetries,
+		baseDelay:  defaultBaseDelay,
+		maxDelay:   defaultMaxDelay,
+	}
+	for _, applyOpt := range opts {
+		applyOpt(t)
+	}
+	return t
+}
+
+// getJSON выполняет GET-запрос и декодирует JSON-ответ в out.
+// Сетевые ошибки и ответы 5xx повторяются с экспоненциальной задержкой, на 429 учитывается Retry-After.
+func (t *transport) getJSON(ctx context.Context, url string, out interface{}) error {
+	for attempt := 0; ; attempt++ {
+		retry, delay, err := t.try(ctx, url, out)
+		if err == nil {
+			return nil
+		}
+		if !retry || attempt >= t.maxRetries {
+			return err
+		}
+		if delay == 0 {
+			delay = t.backoff(attempt)
+		}
+		if delay > t.maxDelay {
+			return err
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return errors.Join(err, ctx.Err())
+		case <-timer.C:
+		}
+	}
+}
+
+// try выполняет одну попытку запроса. Возвращает, стоит ли повторить запрос,
+// и задержку перед повтором, если ее указал сервер.
+func (t *transport) try(ctx context.Context, url string, out interface{}) (bool, time.Duration, error) {
+	if t.limiter != nil {
+		if err := t.limiter.Wait(ctx); err != nil {
+			return false, 0, err
+		}
+	}
+
+	if t.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, t.timeout)
+		defer cancel()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return false, 0, fmt.Errorf("ошибка создания запроса: %v", err)
+	}
+	resp, err := t.client.Do(req)
+	if err != nil {
+		// Отмену запроса не повторяем, таймаут отдельной попытки — повторяем
+		retry := !errors.Is(ctx.Err(), context.Canceled)
+		return retry, 0, &APIError{Message: "ошибка запроса", Err: ErrUnavailable, Cause: err}
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusOK:
+	case resp.StatusCode == http.StatusTooManyRequests:
+		return true, retryAfter(resp.Header.Get("Retry-After")), statusError(resp.StatusCode, resp.Status)
+	case resp.StatusCode >= http.StatusInternalServerError:
+		return true, 0, statusError(resp.StatusCode, resp.Status)
+	default:
+		return false, 0, statusError(resp.StatusCode, resp.Status)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return false, 0, &APIError{Message: "ошибка декодирования ответа", Err: ErrDecode, Cause: err}
+	}
+	return false, 0, nil
+}
+
+// backoff возвращает задержку перед повтором: экспонента от baseDelay со случайным разбросом в пределах половины
+func (t *transport) backoff(attempt int) time.Duration {
+	delay := t.baseDelay << attempt
+	if delay <= 0 || delay > t.maxDelay {
+		delay = t.maxDelay
+	}
+	return delay/2 + rand.N(delay/2+1)
+}
+
+// retryAfter разбирает заголовок Retry-After: число секунд или HTTP-дату
+func retryAfter(header string) time.Duration {
+	if header == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(header); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(header); err == nil {
+		return max(time.Until(date), 0)
+	}
+	return 0
+}
+
+// rateLimiter — ограничитель частоты запросов по алгоритму token bucket
+type rateLimiter struct {
+	mu     sync.Mutex
+	rate   float64 // Токенов в секунду
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newRateLimiter(rate float64, burst int) *rateLimiter {
+	return &rateLimiter{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// Wait блокируется, пока не появится свободный токен или не отменится ctx
+func (l *rateLimiter) Wait(ctx context.Context) error {
+	for {
+		l.mu.Lock()
+		now := time.Now()
+		l.tokens = min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+		l.last = now
+		if l.tokens >= 1 {
+			l.tokens--
+			l.mu.Unlock()
+			return nil
+		}
+		wait := time.Duration((1 - l.tokens) / l.rate * float64(time.Second))
+		l.mu.Unlock()
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
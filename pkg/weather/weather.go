@@ -1,52 +1,165 @@
 package weather
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
-	"net/http"
 	"net/url"
+	"strconv"
+	"time"
 )
 
+const baseURL = "https://api.openweathermap.org"
+
 // Структура для ответа от OpenWeatherMap
 type WeatherResponse struct {
 	Name string `json:"name"` // Название города
 	Main struct {
-		Temp     float64 `json:"temp"`     // Температура
-		Humidity int     `json:"humidity"` // Влажность
+		Temp      float64 `json:"temp"`       // Температура
+		FeelsLike float64 `json:"feels_like"` // Ощущаемая температура
+		Humidity  int     `json:"humidity"`   // Влажность
+		Pressure  float64 `json:"pressure"`   // Давление, гПа
 	} `json:"main"`
 	Weather []struct {
+		ID          int    `json:"id"`          // Код погодных условий
 		Description string `json:"description"` // Описание погоды
 	} `json:"weather"`
+	Wind struct {
+		Speed float64 `json:"speed"` // Скорость ветра
+		Deg   int     `json:"deg"`   // Направление ветра, градусы
+		Gust  float64 `json:"gust"`  // Порывы ветра
+	} `json:"wind"`
+	Clouds struct {
+		All int `json:"all"` // Облачность, %
+	} `json:"clouds"`
+	Visibility int `json:"visibility"` // Видимость, м
+	Sys        struct {
+		Sunrise int64 `json:"sunrise"` // Восход, unix UTC
+		Sunset  int64 `json:"sunset"`  // Закат, unix UTC
+	} `json:"sys"`
+	Timezone int `json:"timezone"` // Смещение от UTC в секундах
 }
 
 // Client — структура для хранения API-ключа и выполнения запросов к OpenWeatherMap
 type Client struct {
-	APIKey string
+	APIKey    string
+	transport *transport
 }
 
+var (
+	_ Provider = (*Client)(nil)
+	_ Geocoder = (*Client)(nil)
+)
+
 // NewClient создает новый экземпляр клиента для OpenWeatherMap
-func New(apiKey string) *Client {
-	return &Client{APIKey: apiKey}
+func New(apiKey string, opts ...Option) *Client {
+	return &Client{
+		APIKey:    apiKey,
+		transport: newTransport(opts...),
+	}
 }
 
-// GetCurrentWeather получает текущую погоду для указанного города
-func (c *Client) GetCurrentWeather(city string) (*WeatherResponse, error) {
-	encodedCity := url.QueryEscape(city)
-	url := fmt.Sprintf("https://api.openweathermap.org/data/2.5/weather?q=%s&appid=%s&units=metric&lang=ru", encodedCity, c.APIKey)
-	resp, err := http.Get(url)
+// Name возвращает название провайдера
+func (c *Client) Name() string {
+	return "openweathermap"
+}
+
+// Current получает текущую погоду в указанном месте
+func (c *Client) Current(ctx context.Context, place Place) (*Conditions, error) {
+	var (
+		weatherData *WeatherResponse
+		err         error
+	)
+	if place.Coords != nil {
+		weatherData, err = c.GetCurrentWeatherByCoords(ctx, place.Coords.Lat, place.Coords.Lon, place.Lang)
+	} else {
+		weatherData, err = c.GetCurrentWeather(ctx, place.City, place.Lang)
+	}
 	if err != nil {
-		return nil, fmt.Errorf("ошибка запроса к OpenWeatherMap: %v", err)
+		return nil, err
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("не удалось получить данные о погоде, статус: %s", resp.Status)
+	loc := time.FixedZone("", weatherData.Timezone)
+	conditions := &Conditions{
+		City:       weatherData.Name,
+		Temp:       weatherData.Main.Temp,
+		FeelsLike:  weatherData.Main.FeelsLike,
+		Humidity:   weatherData.Main.Humidity,
+		Pressure:   weatherData.Main.Pressure,
+		WindSpeed:  weatherData.Wind.Speed,
+		WindGust:   weatherData.Wind.Gust,
+		WindDeg:    weatherData.Wind.Deg,
+		Clouds:     weatherData.Clouds.All,
+		Visibility: weatherData.Visibility,
+		UTCOffset:  weatherData.Timezone,
 	}
+	if weatherData.Sys.Sunrise != 0 {
+		conditions.Sunrise = time.Unix(weatherData.Sys.Sunrise, 0).In(loc)
+		conditions.Sunset = time.Unix(weatherData.Sys.Sunset, 0).In(loc)
+	}
+	if len(weatherData.Weather) > 0 {
+		conditions.Description = weatherData.Weather[0].Description
+		conditions.Condition = owmCondition(weatherData.Weather[0].ID)
+	}
+	return conditions, nil
+}
 
-	var weatherData WeatherResponse
-	if err := json.NewDecoder(resp.Body).Decode(&weatherData); err != nil {
-		return nil, fmt.Errorf("ошибка декодирования ответа: %v", err)
+// owmCondition возвращает класс погодных условий по коду OpenWeatherMap
+func owmCondition(id int) Condition {
+	switch {
+	case id >= 200 && id < 300:
+		return ConditionThunderstorm
+	case id >= 300 && id < 400:
+		return ConditionDrizzle
+	case id >= 500 && id < 600:
+		return ConditionRain
+	case id >= 600 && id < 700:
+		return ConditionSnow
+	case id >= 700 && id < 800:
+		return ConditionFog
+	case id == 800:
+		return ConditionClear
+	case id == 801 || id == 802:
+		return ConditionPartlyCloudy
+	case id > 802 && id < 900:
+		return ConditionCloudy
+	default:
+		return ConditionUnknown
 	}
+}
 
+// GetCurrentWeather получает текущую погоду для указанного города с описанием на языке lang
+func (c *Client) GetCurrentWeather(ctx context.Context, city, lang string) (*WeatherResponse, error) {
+	return c.getCurrentWeather(ctx, url.Values{"q": {city}}, lang)
+}
+
+// GetCurrentWeatherByCoords получает текущую погоду по координатам с описанием на языке lang
+func (c *Client) GetCurrentWeatherByCoords(ctx context.Context, lat, lon float64, lang string) (*WeatherResponse, error) {
+	return c.getCurrentWeather(ctx, coordsQuery(lat, lon), lang)
+}
+
+func (c *Client) getCurrentWeather(ctx context.Context, query url.Values, lang string) (*WeatherResponse, error) {
+	query.Set("units", "metric")
+	query.Set("lang", langOrDefault(lang))
+
+	var weatherData WeatherResponse
+	if err := c.get(ctx, "/data/2.5/weather", query, &weatherData); err != nil {
+		return nil, err
+	}
 	return &weatherData, nil
 }
+
+// get выполняет GET-запрос к OpenWeatherMap и декодирует ответ в out
+func (c *Client) get(ctx context.Context, path string, query url.Values, out interface{}) error {
+	query.Set("appid", c.APIKey)
+	if err := c.transport.getJSON(ctx, baseURL+path+"?"+query.Encode(), out); err != nil {
+		return fmt.Errorf("OpenWeatherMap: %w", err)
+	}
+	return nil
+}
+
+func coordsQuery(lat, lon float64) url.Values {
+	return url.Values{
+		"lat": {strconv.FormatFloat(lat, 'f', -1, 64)},
+		"lon": {strconv.FormatFloat(lon, 'f', -1, 64)},
+	}
+}
@@ -0,0 +1,24 @@
+package weather
+
+// compassSectors — число румбов, на которые делится направление ветра
+const compassSectors = 16
+
+// CompassSector возвращает номер румба (0 — С, 1 — ССВ, ..., 15 — ССЗ) для направления ветра в градусах
+func CompassSector(deg int) int {
+	deg = ((deg % 360) + 360) % 360
+	sector := 360.0 / compassSectors
+	return int((float64(deg)+sector/2)/sector) % compassSectors
+}
+
+// beaufortLimits — верхние границы скорости ветра (м/с) для баллов 0–11 шкалы Бофорта
+var beaufortLimits = [...]float64{0.3, 1.6, 3.4, 5.5, 8.0, 10.8, 13.9, 17.2, 20.8, 24.5, 28.5, 32.7}
+
+// Beaufort возвращает балл по шкале Бофорта (0–12) для скорости ветра в м/с
+func Beaufort(speed float64) int {
+	for force, limit := range beaufortLimits {
+		if speed < limit {
+			return force
+		}
+	}
+	return len(beaufortLimits)
+}
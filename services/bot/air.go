@@ -0,0 +1,133 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/ViolettaBykova/viot-tg-sirius/models"
+	"github.com/ViolettaBykova/viot-tg-sirius/pkg/i18n"
+	"github.com/ViolettaBykova/viot-tg-sirius/pkg/weather"
+	tb "gopkg.in/tucnak/telebot.v2"
+)
+
+// MaxAQI — максимальное значение индекса качества воздуха
+const MaxAQI = 5
+
+// GetAirQuality возвращает качество воздуха в месте пользователя
+func (s *Service) GetAirQuality(ctx context.Context, telegramID int64) (*weather.AirQuality, error) {
+	user, err := s.userWithCity(ctx, telegramID)
+	if err != nil {
+		return nil, err
+	}
+	return s.airQuality(ctx, user)
+}
+
+// SetShowAirQuality включает или выключает строку о качестве воздуха в обновлениях
+func (s *Service) SetShowAirQuality(ctx context.Context, telegramID int64, show bool) error {
+	ctx, err := s.store.CtxWithTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = s.store.TxRollback(ctx)
+	}()
+	err = s.store.SetShowAirQuality(ctx, telegramID, show)
+	if err != nil {
+		log.Printf("Failed to set air quality display for user %d: %v", telegramID, err)
+		return err
+	}
+	return s.store.TxCommit(ctx)
+}
+
+// SetAQIAlertLevel устанавливает порог AQI, при превышении которого пользователь получит предупреждение.
+// 0 выключает предупреждения.
+func (s *Service) SetAQIAlertLevel(ctx context.Context, telegramID int64, level int) error {
+	if level < 0 || level > MaxAQI {
+		return fmt.Errorf("aqi alert level %d out of range", level)
+	}
+	ctx, err := s.store.CtxWithTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = s.store.TxRollback(ctx)
+	}()
+	err = s.store.SetAQIAlertLevel(ctx, telegramID, level)
+	if err != nil {
+		log.Printf("Failed to set aqi alert level for user %d: %v", telegramID, err)
+		return err
+	}
+	return s.store.TxCommit(ctx)
+}
+
+// checkAirQuality запрашивает качество воздуха в месте подписки для обновления по расписанию,
+// если оно нужно пользователю. Предупреждает пользователя, когда AQI поднялся до выбранного порога,
+// и возвращает строку для отчета или пустую строку, если показывать ее не нужно.
+func (s *Service) checkAirQuality(ctx context.Context, sub *models.Subscription) string {
+	user := subscriptionUser(sub)
+	if !user.ShowAirQuality && user.AQIAlertLevel == 0 {
+		return ""
+	}
+	air, err := s.airQuality(ctx, user)
+	if err != nil {
+		log.Printf("Ошибка при получении качества воздуха для подписки %d: %v", sub.ID, err)
+		return ""
+	}
+
+	level := user.AQIAlertLevel
+	if level > 0 && air.AQI >= level && sub.LastAQI < level {
+		warning := i18n.T(user.Language, "air.warning", air.City, aqiName(user.Language, air.AQI), air.AQI, air.PM25, air.PM10)
+		if _, err := s.bot.Send(&tb.User{ID: user.TelegramID}, warning); err != nil {
+			log.Printf("Ошибка отправки предупреждения о качестве воздуха пользователю %d: %v", user.TelegramID, err)
+			s.deactivateOnError(ctx, user.TelegramID, err)
+		}
+	}
+	if air.AQI != sub.LastAQI {
+		sub.LastAQI = air.AQI
+		if err := s.updateSubscription(ctx, sub, "last_aqi"); err != nil {
+			log.Printf("Failed to save aqi for subscription %d: %v", sub.ID, err)
+		}
+	}
+
+	if !user.ShowAirQuality {
+		return ""
+	}
+	return i18n.T(user.Language, "air.line", aqiName(user.Language, air.AQI), air.AQI)
+}
+
+// airQuality запрашивает качество воздуха в месте пользователя
+func (s *Service) airQuality(ctx context.Context, user *models.User) (*weather.AirQuality, error) {
+	if s.airAPI == nil {
+		return nil, ErrAirQualityNotAvailable
+	}
+	ctx, cancel := context.WithTimeout(ctx, weatherRequestTimeout)
+	defer cancel()
+
+	coords, err := s.coordsOf(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	air, err := s.airAPI.AirQuality(ctx, coords)
+	if err != nil {
+		return nil, err
+	}
+	air.City = user.City
+	return air, nil
+}
+
+// coordsOf возвращает координаты места пользователя. Для городов, введенных до появления
+// геокодирования, координаты определяются по названию.
+func (s *Service) coordsOf(ctx context.Context, user *models.User) (weather.Coords, error) {
+	if user.Lat != nil && user.Lon != nil {
+		return weather.Coords{Lat: *user.Lat, Lon: *user.Lon}, nil
+	}
+	locations, err := s.geocoder.FindLocations(ctx, user.City, string(user.Language))
+	if err != nil {
+		return weather.Coords{}, err
+	}
+	if len(locations) == 0 {
+		return weather.Coords{}, fmt.Errorf("%q: %w", user.City, weather.ErrCityNotFound)
+	}
+	return weather.Coords{Lat: locations[0].Lat, Lon: locations[0].Lon}, nil
+}
@@ -0,0 +1,167 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/ViolettaBykova/viot-tg-sirius/models"
+	"github.com/ViolettaBykova/viot-tg-sirius/pkg/i18n"
+	"github.com/ViolettaBykova/viot-tg-sirius/pkg/weather"
+	tb "gopkg.in/tucnak/telebot.v2"
+)
+
+// alertRetention — сколько хранить отметки об отправке после окончания действия предупреждения
+const alertRetention = 7 * 24 * time.Hour
+
+// StartAlertPoller планирует опрос предупреждений о неблагоприятной погоде каждые interval.
+// Ничего не делает, если источник предупреждений не подключен.
+func (s *Service) StartAlertPoller(interval time.Duration) error {
+	if s.alertAPI == nil {
+		return nil
+	}
+	_, err := s.cron.AddFunc(fmt.Sprintf("@every %s", interval), func() {
+		s.pollAlerts(context.Background())
+	})
+	return err
+}
+
+// alertGroup — подписки на одно и то же место, владельцы которых читают на одном языке
+type alertGroup struct {
+	place *models.User // Владелец подписки, по месту которой определяются координаты группы
+	users []*models.User
+}
+
+// pollAlerts запрашивает предупреждения один раз для каждого места, на которое есть включенные подписки,
+// и отправляет каждое новое предупреждение затронутым пользователям
+func (s *Service) pollAlerts(ctx context.Context) {
+	subs, err := s.enabledSubscriptions(ctx)
+	if err != nil {
+		log.Printf("Ошибка при загрузке подписок для предупреждений: %v", err)
+		return
+	}
+
+	now := time.Now()
+	groups := make(map[string]*alertGroup)
+	for i := range subs {
+		if subs[i].User.IsPaused(now) {
+			continue
+		}
+		user := subscriptionUser(&subs[i])
+		key := string(user.Language) + ":" + placeOf(user).Key()
+		group, ok := groups[key]
+		if !ok {
+			group = &alertGroup{place: user}
+			groups[key] = group
+		}
+		group.users = append(group.users, user)
+	}
+
+	for key, group := range groups {
+		alerts, err := s.alertsFor(ctx, group.place)
+		if err != nil {
+			log.Printf("Ошибка при получении предупреждений для %s: %v", key, err)
+			continue
+		}
+		for _, alert := range alerts {
+			for _, user := range group.users {
+				s.pushAlert(ctx, user, alert)
+			}
+		}
+	}
+
+	s.deleteExpiredAlerts(ctx)
+}
+
+func (s *Service) alertsFor(ctx context.Context, user *models.User) ([]weather.Alert, error) {
+	ctx, cancel := context.WithTimeout(ctx, weatherRequestTimeout)
+	defer cancel()
+
+	coords, err := s.coordsOf(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	return s.alertAPI.Alerts(ctx, coords, string(user.Language))
+}
+
+// pushAlert отправляет предупреждение пользователю, если оно не было отправлено раньше.
+// Отметка ставится на пользователя, поэтому несколько подписок на одно место не дублируют сообщение.
+func (s *Service) pushAlert(ctx context.Context, user *models.User, alert weather.Alert) {
+	claimed, err := s.claimAlert(ctx, alert, user.TelegramID)
+	if err != nil {
+		log.Printf("Ошибка при сохранении предупреждения %s для пользователя %d: %v", alert.ID, user.TelegramID, err)
+		return
+	}
+	if !claimed {
+		return
+	}
+
+	if _, err := s.bot.Send(&tb.User{ID: user.TelegramID}, formatAlert(user.Language, user.City, alert)); err != nil {
+		log.Printf("Ошибка отправки предупреждения %s пользователю %d: %v", alert.ID, user.TelegramID, err)
+		if s.deactivateOnError(ctx, user.TelegramID, err) {
+			return
+		}
+		s.releaseAlert(ctx, alert, user.TelegramID)
+	}
+}
+
+func (s *Service) claimAlert(ctx context.Context, alert weather.Alert, telegramID int64) (bool, error) {
+	ctx, err := s.store.CtxWithTx(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer func() {
+		_ = s.store.TxRollback(ctx)
+	}()
+	claimed, err := s.store.ClaimAlert(ctx, alert.ID, telegramID, alert.End.Add(alertRetention))
+	if err != nil {
+		return false, err
+	}
+	return claimed, s.store.TxCommit(ctx)
+}
+
+func (s *Service) releaseAlert(ctx context.Context, alert weather.Alert, telegramID int64) {
+	ctx, err := s.store.CtxWithTx(ctx)
+	if err != nil {
+		return
+	}
+	defer func() {
+		_ = s.store.TxRollback(ctx)
+	}()
+	if err := s.store.ReleaseAlert(ctx, alert.ID, telegramID); err != nil {
+		log.Printf("Ошибка при снятии отметки о предупреждении %s для пользователя %d: %v", alert.ID, telegramID, err)
+		return
+	}
+	_ = s.store.TxCommit(ctx)
+}
+
+func (s *Service) deleteExpiredAlerts(ctx context.Context) {
+	ctx, err := s.store.CtxWithTx(ctx)
+	if err != nil {
+		return
+	}
+	defer func() {
+		_ = s.store.TxRollback(ctx)
+	}()
+	if err := s.store.DeleteExpiredAlerts(ctx, time.Now()); err != nil {
+		log.Printf("Ошибка при удалении устаревших предупреждений: %v", err)
+		return
+	}
+	_ = s.store.TxCommit(ctx)
+}
+
+// formatAlert формирует сообщение с предупреждением на языке lang
+func formatAlert(lang i18n.Lang, city string, alert weather.Alert) string {
+	var sb strings.Builder
+	sb.WriteString(i18n.T(lang, "alert.title", city, alert.Event) + "\n")
+	sb.WriteString(i18n.T(lang, "alert.period", alert.Start.Format("02.01 15:04"), alert.End.Format("02.01 15:04")) + "\n")
+	if alert.Description != "" {
+		fmt.Fprintf(&sb, "\n%s\n", alert.Description)
+	}
+	if alert.Sender != "" {
+		sb.WriteString("\n" + i18n.T(lang, "alert.source", alert.Sender))
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
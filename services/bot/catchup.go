@@ -0,0 +1,74 @@
+package bot
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/ViolettaBykova/viot-tg-sirius/models"
+	"github.com/ViolettaBykova/viot-tg-sirius/pkg/i18n"
+)
+
+// CatchUpPolicy — что делать с уведомлениями, пропущенными, пока сервис не работал
+type CatchUpPolicy string
+
+const (
+	CatchUpSkip   CatchUpPolicy = "skip"   // Не отправлять пропущенное, ждать следующего срабатывания расписания
+	CatchUpLatest CatchUpPolicy = "latest" // Отправить одно обычное обновление вместо всех пропущенных
+	CatchUpDigest CatchUpPolicy = "digest" // Отправить одно обновление со сводкой о пропущенных
+)
+
+// DefaultCatchUpPolicy — политика догоняющих уведомлений по умолчанию
+const DefaultCatchUpPolicy = CatchUpLatest
+
+// catchUpGrace — насколько срабатывание может опоздать к моменту, когда диспетчер забирает подписку,
+// чтобы еще считаться вовремя, а не пропущенным за время простоя
+const catchUpGrace = time.Minute
+
+// ParseCatchUpPolicy разбирает название политики догоняющих уведомлений
+func ParseCatchUpPolicy(name string) (CatchUpPolicy, error) {
+	switch policy := CatchUpPolicy(name); policy {
+	case CatchUpSkip, CatchUpLatest, CatchUpDigest:
+		return policy, nil
+	}
+	return "", fmt.Errorf("unknown catch-up policy %q", name)
+}
+
+// missedRuns — срабатывания расписания подписки, наступившие к моменту, когда диспетчер ее забрал
+type missedRuns struct {
+	total  int       // Всего наступивших срабатываний
+	missed int       // Из них опоздали больше чем на catchUpGrace: сервис в это время не работал
+	first  time.Time // Первое пропущенное срабатывание
+	last   time.Time // Последнее пропущенное срабатывание
+	latest time.Time // Последнее наступившее срабатывание, за него ставится уведомление
+}
+
+// catchUpHeader возвращает строку, с которой начинается обновление по подписке после простоя,
+// и false, если по политике обновление отправлять не нужно
+func (s *Service) catchUpHeader(sub *models.Subscription, runs missedRuns) (string, bool) {
+	if runs.missed == 0 {
+		return "", true
+	}
+	switch s.catchUp {
+	case CatchUpSkip:
+		// Отправляем, только если последнее срабатывание не опоздало
+		return "", runs.missed < runs.total
+	case CatchUpDigest:
+		return missedDigest(sub, runs), true
+	}
+	return "", true
+}
+
+// missedDigest описывает пропущенные за время простоя уведомления на языке пользователя
+func missedDigest(sub *models.Subscription, runs missedRuns) string {
+	lang := sub.User.Language
+	loc, err := LoadTimezone(sub.User.Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+	digest := i18n.T(lang, "catchup.digest", runs.missed,
+		runs.first.In(loc).Format("02.01 15:04"), runs.last.In(loc).Format("02.01 15:04"))
+	if sub.LastDeliveredAt != nil {
+		digest += "\n" + i18n.T(lang, "catchup.last_delivered", sub.LastDeliveredAt.In(loc).Format("02.01 15:04"))
+	}
+	return digest + "\n" + i18n.T(lang, "catchup.now")
+}
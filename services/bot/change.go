@@ -0,0 +1,96 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ViolettaBykova/viot-tg-sirius/models"
+	"github.com/ViolettaBykova/viot-tg-sirius/models/units"
+	"github.com/ViolettaBykova/viot-tg-sirius/pkg/weather"
+)
+
+// maxThreshold ограничивает порог изменения температуры и ветра в единицах пользователя
+const maxThreshold = 100
+
+// conditionClasses объединяет классы погодных условий, смена которых внутри группы не считается заметной:
+// например, ясно и облачно — это одинаково сухая погода
+var conditionClasses = map[weather.Condition]string{
+	weather.ConditionClear:        "dry",
+	weather.ConditionPartlyCloudy: "dry",
+	weather.ConditionCloudy:       "dry",
+	weather.ConditionFog:          "fog",
+	weather.ConditionDrizzle:      "rain",
+	weather.ConditionRain:         "rain",
+	weather.ConditionSnow:         "snow",
+	weather.ConditionThunderstorm: "thunderstorm",
+}
+
+// significantChange сообщает, нужно ли отправить погоду c по подписке. В режиме уведомлений только
+// при изменениях погода сравнивается с последней отправленной: температура или ветер должны измениться
+// не меньше чем на порог подписки, либо должна смениться группа погодных условий, например сухо на дождь.
+func significantChange(sub *models.Subscription, c *weather.Conditions) bool {
+	if !sub.OnlyOnChange || sub.LastSent == nil {
+		return true
+	}
+	last := sub.LastSent
+	if sub.TempThreshold > 0 && math.Abs(c.Temp-last.Temp) >= sub.TempThreshold {
+		return true
+	}
+	if sub.WindThreshold > 0 && math.Abs(c.WindSpeed-last.WindSpeed) >= sub.WindThreshold {
+		return true
+	}
+	class, lastClass := conditionClasses[c.Condition], conditionClasses[weather.Condition(last.Condition)]
+	return class != "" && lastClass != "" && class != lastClass
+}
+
+// snapshotOf возвращает снимок погоды c, отправленной в момент now
+func snapshotOf(c *weather.Conditions, now time.Time) *models.WeatherSnapshot {
+	return &models.WeatherSnapshot{
+		Temp:      c.Temp,
+		WindSpeed: c.WindSpeed,
+		Condition: string(c.Condition),
+		SentAt:    now,
+	}
+}
+
+// SetSubscriptionOnlyOnChange включает или выключает режим, в котором уведомление по подписке
+// отправляется, только если погода заметно изменилась с последнего отправленного
+func (s *Service) SetSubscriptionOnlyOnChange(ctx context.Context, telegramID, subscriptionID int64, on bool) error {
+	sub := &models.Subscription{ID: subscriptionID, TelegramID: telegramID, OnlyOnChange: on}
+	return s.updateSubscription(ctx, sub, "only_on_change")
+}
+
+// SetSubscriptionThresholds задает пороги изменения температуры и ветра из текста вида «3 5»
+// в единицах пользователя u. Порог 0 не учитывается.
+func (s *Service) SetSubscriptionThresholds(ctx context.Context, telegramID, subscriptionID int64, text string, u units.Units) error {
+	temp, wind, err := parseThresholds(text, u)
+	if err != nil {
+		return err
+	}
+	sub := &models.Subscription{ID: subscriptionID, TelegramID: telegramID, TempThreshold: temp, WindThreshold: wind}
+	return s.updateSubscription(ctx, sub, "temp_threshold", "wind_threshold")
+}
+
+// parseThresholds разбирает пороги температуры и ветра в единицах u и переводит их в °C и м/с
+func parseThresholds(text string, u units.Units) (temp, wind float64, err error) {
+	fields := strings.FieldsFunc(text, func(r rune) bool {
+		return r == ' ' || r == ',' || r == ';' || r == '/'
+	})
+	if len(fields) != 2 {
+		return 0, 0, fmt.Errorf("%q: %w", text, ErrInvalidThresholds)
+	}
+	values := make([]float64, len(fields))
+	for i, field := range fields {
+		v, err := strconv.ParseFloat(field, 64)
+		if err != nil || v < 0 || v > maxThreshold {
+			return 0, 0, fmt.Errorf("%q: %w", text, ErrInvalidThresholds)
+		}
+		values[i] = v
+	}
+	// Перевод единиц линейный, поэтому разность переводится делением на цену одного градуса или м/с
+	return values[0] / (u.Temp(1) - u.Temp(0)), values[1] / u.Speed(1), nil
+}
@@ -0,0 +1,323 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/ViolettaBykova/viot-tg-sirius/models"
+	tb "gopkg.in/tucnak/telebot.v2"
+)
+
+// DefaultDeliveryAttempts — сколько раз по умолчанию пытаться отправить уведомление, прежде чем перевести его в dead
+const DefaultDeliveryAttempts = 5
+
+// DefaultDeliveryRetention — сколько по умолчанию хранить завершенные уведомления
+const DefaultDeliveryRetention = 7 * 24 * time.Hour
+
+// errDeliveryLost — уведомление уже не принадлежит этому экземпляру: его посчитали прерванным
+var errDeliveryLost = errors.New("delivery is no longer being sent by this instance")
+
+const (
+	deliveryBackoff    = 30 * time.Second // Пауза перед второй попыткой, дальше она удваивается
+	maxDeliveryBackoff = time.Hour        // Самая длинная пауза между попытками
+	// deliveryLease — сколько может длиться отправка. Не завершенные и не продленные за это время уведомления
+	// считаются прерванными падением сервиса. Пока пачка уведомлений отправляется, экземпляр продлевает ее
+	// каждые deliveryLease/3.
+	deliveryLease = 5 * time.Minute
+	// deliveryCleanupBatch — сколько завершенных уведомлений удалять одной транзакцией
+	deliveryCleanupBatch = 1000
+)
+
+// runSender раз в dispatchInterval отправляет уведомления из очереди. Перед отправкой уведомление
+// помечается как отправляемое в отдельной транзакции, поэтому после падения сервиса оно не уйдет второй раз.
+func (s *Service) runSender(ctx context.Context) {
+	defer close(s.senderDone)
+
+	ticker := time.NewTicker(s.dispatchInterval)
+	defer ticker.Stop()
+	for {
+		s.abandonDeliveries(ctx)
+		s.sendDeliveries(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// sendDeliveries отправляет уведомления, время попытки которых наступило, пачками по dispatchBatch
+func (s *Service) sendDeliveries(ctx context.Context) {
+	var stats fanOutStats
+	defer s.logFanOut(&stats)
+
+	for ctx.Err() == nil {
+		deliveries, err := s.claimDeliveries(ctx, time.Now())
+		if err != nil {
+			log.Printf("Ошибка при выборе уведомлений для отправки: %v", err)
+			return
+		}
+		release := s.holdDeliveries(ctx, deliveries)
+		if s.dispatchWorkers > 0 {
+			stats.add(s.fanOutDeliveries(ctx, deliveries))
+		} else {
+			for i := range deliveries {
+				s.sendDelivery(ctx, &deliveries[i])
+			}
+		}
+		release()
+		if len(deliveries) < s.dispatchBatch {
+			return
+		}
+	}
+}
+
+// claimDeliveries забирает уведомления, время попытки которых наступило к now, и помечает их как отправляемые
+func (s *Service) claimDeliveries(ctx context.Context, now time.Time) ([]models.Delivery, error) {
+	ctx, err := s.store.CtxWithTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = s.store.TxRollback(ctx)
+	}()
+
+	deliveries, err := s.store.ClaimDeliveries(ctx, now, s.dispatchBatch)
+	if err != nil {
+		return nil, err
+	}
+	for i := range deliveries {
+		deliveries[i].Status = models.DeliverySending
+		deliveries[i].Attempts++
+		deliveries[i].UpdatedAt = now
+	}
+	if err := s.store.UpdateDeliveries(ctx, deliveries, "status", "attempts", "updated_at"); err != nil {
+		return nil, err
+	}
+	return deliveries, s.store.TxCommit(ctx)
+}
+
+// holdDeliveries продлевает отправку пачки уведомлений каждые deliveryLease/3, пока не будет вызвана
+// возвращенная функция. Без этого уведомления в конце долгой пачки другой экземпляр посчитал бы прерванными.
+func (s *Service) holdDeliveries(ctx context.Context, deliveries []models.Delivery) (release func()) {
+	ids := make([]int64, 0, len(deliveries))
+	for _, d := range deliveries {
+		ids = append(ids, d.ID)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(deliveryLease / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			// Уже отправленные уведомления не в статусе sending и не продлеваются
+			if _, err := s.renewDeliveries(ctx, ids); err != nil {
+				log.Printf("Ошибка продления отправки уведомлений: %v", err)
+			}
+		}
+	}()
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+// stillSending продлевает отправку уведомления перед отправкой сообщения и сообщает, что оно все еще
+// принадлежит этому экземпляру. Уведомление, которое посчитали прерванным, не отправляется.
+func (s *Service) stillSending(ctx context.Context, d *models.Delivery) bool {
+	n, err := s.renewDeliveries(ctx, []int64{d.ID})
+	if err != nil {
+		log.Printf("Ошибка продления отправки уведомления %d: %v", d.ID, err)
+		return false
+	}
+	if n == 0 {
+		log.Printf("Уведомление %d уже считается прерванным, отправка отменена", d.ID)
+		return false
+	}
+	return true
+}
+
+func (s *Service) renewDeliveries(ctx context.Context, ids []int64) (int, error) {
+	ctx, err := s.store.CtxWithTx(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		_ = s.store.TxRollback(ctx)
+	}()
+
+	n, err := s.store.RenewDeliveries(ctx, ids, time.Now())
+	if err != nil {
+		return 0, err
+	}
+	return n, s.store.TxCommit(ctx)
+}
+
+// sendDelivery отправляет уведомление и записывает результат
+func (s *Service) sendDelivery(ctx context.Context, d *models.Delivery) {
+	if !s.stillSending(ctx, d) {
+		return
+	}
+	msg, err := s.sendSubscriptionUpdate(ctx, d.Subscription, d.Header)
+	s.recordAttempt(ctx, d, msg, err)
+}
+
+// recordAttempt записывает результат попытки отправить уведомление. Неудачная попытка повторяется с растущей паузой,
+// после deliveryAttempts попыток уведомление переводится в dead и пользователю сообщается об ошибке.
+func (s *Service) recordAttempt(ctx context.Context, d *models.Delivery, msg *tb.Message, err error) {
+	sub := d.Subscription
+	now := time.Now()
+	d.UpdatedAt = now
+	switch {
+	case err == nil:
+		d.Status = models.DeliverySent
+		d.MessageID = &msg.ID
+		d.SentAt = &now
+		d.LastError = ""
+	case errors.Is(err, errUpdateSkipped):
+		d.Status = models.DeliverySkipped
+		d.LastError = err.Error()
+	case s.deactivateOnError(ctx, sub.TelegramID, err):
+		// Бот больше не может писать пользователю, повторять бесполезно
+		d.Status = models.DeliveryDead
+		d.LastError = err.Error()
+	case NeedsCity(err) || d.Attempts >= s.deliveryAttempts:
+		log.Printf("Error sending weather update for subscription %d, giving up after %d attempts: %v",
+			sub.ID, d.Attempts, err)
+		d.Status = models.DeliveryDead
+		d.LastError = err.Error()
+		s.handleUpdateError(ctx, sub, err)
+	default:
+		log.Printf("Error sending weather update for subscription %d, attempt %d: %v", sub.ID, d.Attempts, err)
+		d.Status = models.DeliveryPending
+		d.LastError = err.Error()
+		d.NextAttemptAt = now.Add(retryBackoff(d.Attempts))
+	}
+
+	// Результат сохраняется и при остановке сервиса, иначе отправленное уведомление осталось бы прерванным
+	switch err := s.finishDelivery(context.WithoutCancel(ctx), d); {
+	case errors.Is(err, errDeliveryLost):
+		log.Printf("Delivery %d was marked as interrupted by another instance, result %s is not saved", d.ID, d.Status)
+	case err != nil:
+		log.Printf("Failed to save delivery %d: %v", d.ID, err)
+	}
+}
+
+// finishDelivery сохраняет результат попытки, а после успешной отправки — и время доставки по подписке
+// вместе с отправленной погодой. Возвращает errDeliveryLost, если уведомление уже не отправляется этим экземпляром.
+func (s *Service) finishDelivery(ctx context.Context, d *models.Delivery) error {
+	ctx, err := s.store.CtxWithTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = s.store.TxRollback(ctx)
+	}()
+
+	updated, err := s.store.UpdateDelivery(ctx, d, "status", "message_id", "last_error", "next_attempt_at", "sent_at", "updated_at")
+	if err != nil {
+		return err
+	}
+	if !updated {
+		return errDeliveryLost
+	}
+	if d.Status == models.DeliverySent {
+		// Время последней доставки нужно, чтобы после простоя рассказать, когда пришло последнее обновление
+		d.Subscription.LastDeliveredAt = d.SentAt
+		if err := s.store.UpdateSubscription(ctx, d.Subscription, "last_delivered_at", "last_sent"); err != nil {
+			return err
+		}
+	}
+	return s.store.TxCommit(ctx)
+}
+
+// abandonDeliveries переводит в dead уведомления, отправка которых прервалась. Уведомление могло дойти
+// до пользователя, поэтому его лучше потерять, чем отправить дважды.
+func (s *Service) abandonDeliveries(ctx context.Context) {
+	ctx, err := s.store.CtxWithTx(ctx)
+	if err != nil {
+		return
+	}
+	defer func() {
+		_ = s.store.TxRollback(ctx)
+	}()
+
+	n, err := s.store.AbandonDeliveries(ctx, time.Now().Add(-deliveryLease), "interrupted while sending")
+	if err != nil {
+		log.Printf("Ошибка при поиске прерванных уведомлений: %v", err)
+		return
+	}
+	if n > 0 {
+		log.Printf("Прервана отправка уведомлений: %d, повторно они не отправляются", n)
+	}
+	_ = s.store.TxCommit(ctx)
+}
+
+// StartDeliveryCleanup планирует ежечасное удаление завершенных уведомлений старше retention,
+// чтобы очередь не росла без ограничений. При retention <= 0 уведомления хранятся бессрочно.
+func (s *Service) StartDeliveryCleanup(retention time.Duration) error {
+	if retention <= 0 {
+		return nil
+	}
+	_, err := s.cron.AddFunc("@every 1h", func() {
+		s.deleteFinishedDeliveries(context.Background(), time.Now().Add(-retention))
+	})
+	return err
+}
+
+// deleteFinishedDeliveries удаляет завершенные уведомления, измененные раньше before, пачками
+// по deliveryCleanupBatch, чтобы не держать долгую транзакцию
+func (s *Service) deleteFinishedDeliveries(ctx context.Context, before time.Time) {
+	total := 0
+	defer func() {
+		if total > 0 {
+			log.Printf("Удалено завершенных уведомлений: %d", total)
+		}
+	}()
+
+	for ctx.Err() == nil {
+		n, err := s.deleteFinishedBatch(ctx, before)
+		if err != nil {
+			log.Printf("Ошибка при удалении завершенных уведомлений: %v", err)
+			return
+		}
+		total += n
+		if n < deliveryCleanupBatch {
+			return
+		}
+	}
+}
+
+func (s *Service) deleteFinishedBatch(ctx context.Context, before time.Time) (int, error) {
+	ctx, err := s.store.CtxWithTx(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		_ = s.store.TxRollback(ctx)
+	}()
+
+	n, err := s.store.DeleteFinishedDeliveries(ctx, before, deliveryCleanupBatch)
+	if err != nil {
+		return 0, err
+	}
+	return n, s.store.TxCommit(ctx)
+}
+
+// retryBackoff возвращает паузу перед следующей попыткой после attempts неудачных
+func retryBackoff(attempts int) time.Duration {
+	backoff := deliveryBackoff
+	for i := 1; i < attempts && backoff < maxDeliveryBackoff; i++ {
+		backoff *= 2
+	}
+	return min(backoff, maxDeliveryBackoff)
+}
@@ -0,0 +1,188 @@
+package bot
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/ViolettaBykova/viot-tg-sirius/models"
+	"github.com/robfig/cron/v3"
+)
+
+// DefaultDispatchInterval — как часто диспетчер по умолчанию проверяет подписки, которым пора отправить погоду
+const DefaultDispatchInterval = time.Second
+
+// DefaultDispatchBatch — сколько подписок диспетчер по умолчанию забирает из базы за раз
+const DefaultDispatchBatch = 100
+
+// maxCountedMissed ограничивает подсчет пропущенных уведомлений, чтобы частое расписание после долгого простоя
+// не перебиралось по одному срабатыванию
+const maxCountedMissed = 10000
+
+// runDispatcher раз в dispatchInterval рассылает обновления по подпискам, время которых наступило.
+// Каждая подписка хранит время следующего уведомления в базе, поэтому работа за проход пропорциональна
+// числу наступивших уведомлений, а не числу подписок, и ничего не теряется при перезапуске.
+func (s *Service) runDispatcher(ctx context.Context) {
+	defer close(s.dispatchDone)
+
+	ticker := time.NewTicker(s.dispatchInterval)
+	defer ticker.Stop()
+	for {
+		s.dispatch(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// dispatch забирает наступившие подписки пачками по dispatchBatch, пока очередь не опустеет,
+// и ставит уведомления по ним в очередь на отправку.
+// Сколько уведомлений пропущено за время простоя, записывается в лог по каждому пользователю.
+func (s *Service) dispatch(ctx context.Context) {
+	missed := make(map[int64]int)
+	defer func() {
+		for telegramID, n := range missed {
+			log.Printf("Пользователь %d: пропущено уведомлений, пока бот не работал: %d (политика %s)",
+				telegramID, n, s.catchUp)
+		}
+	}()
+
+	for ctx.Err() == nil {
+		due, claimed, err := s.claimDue(ctx, time.Now())
+		if err != nil {
+			log.Printf("Ошибка при выборе подписок для рассылки: %v", err)
+			return
+		}
+		for _, run := range due {
+			if run.runs.missed > 0 {
+				missed[run.sub.TelegramID] += run.runs.missed
+			}
+		}
+		if claimed < s.dispatchBatch {
+			return
+		}
+	}
+}
+
+// dueRun — забранная из очереди подписка и ее наступившие срабатывания
+type dueRun struct {
+	sub  models.Subscription
+	runs missedRuns
+}
+
+// claimDue забирает наступившие подписки и в той же транзакции переносит их next_run_at на следующее
+// срабатывание расписания и ставит уведомления в очередь на отправку с учетом политики после простоя.
+// Подписка отдается одному экземпляру, даже если их несколько.
+// Возвращает наступившие подписки и сколько строк было забрано из очереди.
+func (s *Service) claimDue(ctx context.Context, now time.Time) ([]dueRun, int, error) {
+	ctx, err := s.store.CtxWithTx(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer func() {
+		_ = s.store.TxRollback(ctx)
+	}()
+
+	subs, err := s.store.ClaimDueSubscriptions(ctx, now, s.dispatchBatch)
+	if err != nil {
+		return nil, 0, err
+	}
+	// Работающий диспетчер забирает подписку за секунды, поэтому срабатывание, опоздавшее больше чем на
+	// catchUpGrace, некому было отправить: сервис в это время не работал. Опоздание считается по расписанию
+	// каждой подписки, а не от запуска экземпляра, поэтому перезапуск одного из нескольких экземпляров
+	// не выдает за простой уведомления, которые остальные отправляют вовремя.
+	missedBefore := now.Add(-catchUpGrace)
+	due := make([]dueRun, 0, len(subs))
+	deliveries := make([]models.Delivery, 0, len(subs))
+	for i := range subs {
+		sub := &subs[i]
+		scheds, err := schedules(sub.Schedule, sub.User.Timezone)
+		if err != nil {
+			// Такую подписку нельзя запланировать, она выпадает из очереди до изменения расписания
+			log.Printf("Ошибка расписания подписки %d: %v", sub.ID, err)
+			sub.NextRunAt = nil
+			continue
+		}
+		from := afterPause(scheds, *sub.NextRunAt, sub.User)
+		if from.After(now) {
+			sub.NextRunAt = &from
+			continue
+		}
+		next, runs := advance(scheds, from, now, missedBefore)
+		if runs.total > 1 {
+			log.Printf("Подписка %d: наступило уведомлений: %d, из них за время простоя: %d, первое по расписанию в %s",
+				sub.ID, runs.total, runs.missed, sub.NextRunAt.Format(time.RFC3339))
+		}
+		sub.NextRunAt = next
+		due = append(due, dueRun{sub: *sub, runs: runs})
+
+		d := models.Delivery{
+			SubscriptionID: sub.ID,
+			TelegramID:     sub.TelegramID,
+			ScheduledAt:    runs.latest,
+			Status:         models.DeliveryPending,
+			NextAttemptAt:  now,
+		}
+		header, ok := s.catchUpHeader(sub, runs)
+		if !ok {
+			d.Status = models.DeliverySkipped
+			d.LastError = "missed while the service was down, catch-up policy skip"
+		}
+		d.Header = header
+		deliveries = append(deliveries, d)
+	}
+	if err := s.store.SetNextRuns(ctx, subs); err != nil {
+		return nil, 0, err
+	}
+	if err := s.store.CreateDeliveries(ctx, deliveries); err != nil {
+		return nil, 0, err
+	}
+	return due, len(subs), s.store.TxCommit(ctx)
+}
+
+// afterPause возвращает первое срабатывание расписания не раньше конца паузы пользователя, если due
+// пришлось на паузу, иначе due. Срабатывания за время паузы не отправляются и не считаются пропущенными.
+func afterPause(scheds []cron.Schedule, due time.Time, user *models.User) time.Time {
+	if !user.Paused || user.PausedUntil == nil || !due.Before(*user.PausedUntil) {
+		return due
+	}
+	if next := nextRuns(scheds, user.PausedUntil.Add(-time.Second), 1); len(next) > 0 {
+		return next[0]
+	}
+	return due
+}
+
+// advance возвращает следующее после now срабатывание расписания, отсчитывая от наступившего срабатывания due,
+// чтобы интервалы не сдвигались на задержку диспетчера, и сколько срабатываний между due и now наступило.
+// Срабатывания раньше missedBefore считаются пропущенными за время простоя.
+func advance(scheds []cron.Schedule, due, now, missedBefore time.Time) (*time.Time, missedRuns) {
+	var runs missedRuns
+	count := func(run time.Time) {
+		runs.total++
+		runs.latest = run
+		if run.Before(missedBefore) {
+			if runs.missed == 0 {
+				runs.first = run
+			}
+			runs.missed++
+			runs.last = run
+		}
+	}
+
+	count(due)
+	next := nextRuns(scheds, due, 1)
+	for len(next) > 0 && !next[0].After(now) {
+		if runs.total >= maxCountedMissed {
+			next = nextRuns(scheds, now, 1)
+			break
+		}
+		count(next[0])
+		next = nextRuns(scheds, next[0], 1)
+	}
+	if len(next) == 0 {
+		return nil, runs
+	}
+	return &next[0], runs
+}
@@ -0,0 +1,144 @@
+package bot
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ViolettaBykova/viot-tg-sirius/models"
+	"github.com/robfig/cron/v3"
+)
+
+// benchSubscriptions — сколько подписок забирает диспетчер в бенчмарках
+const benchSubscriptions = 100_000
+
+// benchSchedules и benchTimezones смешиваются в подписках бенчмарков
+var (
+	benchSchedules = []string{"1h", "at 07:30,19:00 on 1-5", "cron 0 */3 * * *"}
+	benchTimezones = []string{"Europe/Moscow", "America/New_York", "Asia/Kolkata", "Australia/Sydney", "+05:00", ""}
+)
+
+// benchStore — хранилище в памяти, которое отдает подписки диспетчеру пачками.
+// Остальные методы Storage бенчмаркам не нужны.
+type benchStore struct {
+	Storage
+	subs    []models.Subscription
+	claimed int
+}
+
+func (s *benchStore) CtxWithTx(ctx context.Context) (context.Context, error) { return ctx, nil }
+func (s *benchStore) TxCommit(ctx context.Context) error                     { return nil }
+func (s *benchStore) TxRollback(ctx context.Context) error                   { return nil }
+
+func (s *benchStore) ClaimDueSubscriptions(ctx context.Context, now time.Time, limit int) ([]models.Subscription, error) {
+	n := min(limit, len(s.subs)-s.claimed)
+	batch := make([]models.Subscription, n)
+	copy(batch, s.subs[s.claimed:])
+	s.claimed += n
+	return batch, nil
+}
+
+func (s *benchStore) SetNextRuns(ctx context.Context, subs []models.Subscription) error {
+	return nil
+}
+
+func (s *benchStore) CreateDeliveries(ctx context.Context, deliveries []models.Delivery) error {
+	return nil
+}
+
+// benchSeed — подписки бенчмарков и их разобранные расписания. Создаются один раз: бенчмарк вызывается
+// несколько раз с разным b.N, а подготовка 100 тысяч подписок занимает заметное время.
+var benchSeed = sync.OnceValues(func() (benchData, error) {
+	return seedSubscriptions(benchSubscriptions, benchNow)
+})
+
+type benchData struct {
+	subs   []models.Subscription
+	scheds [][]cron.Schedule
+}
+
+// seedSubscriptions создает n подписок с разными расписаниями и часовыми поясами, у каждой из которых
+// наступило последнее срабатывание расписания не позже now
+func seedSubscriptions(n int, now time.Time) (benchData, error) {
+	users := make([]*models.User, len(benchTimezones))
+	for i, timezone := range benchTimezones {
+		users[i] = &models.User{TelegramID: int64(i + 1), Timezone: timezone}
+	}
+
+	data := benchData{
+		subs:   make([]models.Subscription, n),
+		scheds: make([][]cron.Schedule, n),
+	}
+	for i := range data.subs {
+		user := users[i%len(users)]
+		schedule := benchSchedules[i%len(benchSchedules)]
+		sched, err := schedules(schedule, user.Timezone)
+		if err != nil {
+			return benchData{}, err
+		}
+
+		due := nextRuns(sched, now.Add(-4*24*time.Hour), 1)[0]
+		for next := nextRuns(sched, due, 1)[0]; !next.After(now); next = nextRuns(sched, next, 1)[0] {
+			due = next
+		}
+		data.subs[i] = models.Subscription{
+			ID:         int64(i + 1),
+			TelegramID: user.TelegramID,
+			Schedule:   schedule,
+			Enabled:    true,
+			NextRunAt:  &due,
+			User:       user,
+		}
+		data.scheds[i] = sched
+	}
+	return data, nil
+}
+
+// benchNow — момент проверки в бенчмарках: понедельник, когда срабатывают все виды расписаний
+var benchNow = time.Date(2024, time.March, 4, 9, 0, 0, 0, time.UTC)
+
+func BenchmarkAdvance(b *testing.B) {
+	data, err := benchSeed()
+	if err != nil {
+		b.Fatal(err)
+	}
+	subs, scheds := data.subs, data.scheds
+	missedBefore := benchNow.Add(-catchUpGrace)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := range b.N {
+		j := i % len(subs)
+		advance(scheds[j], *subs[j].NextRunAt, benchNow, missedBefore)
+	}
+}
+
+// BenchmarkClaimDue забирает все наступившие подписки пачками, как диспетчер за один проход.
+// Одна операция — проход по всем подпискам, ns/sub — время на одну подписку.
+func BenchmarkClaimDue(b *testing.B) {
+	data, err := benchSeed()
+	if err != nil {
+		b.Fatal(err)
+	}
+	subs := data.subs
+	store := &benchStore{subs: subs}
+	s := New(store, nil, cron.New(), nil, nil)
+	ctx := context.Background()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for range b.N {
+		store.claimed = 0
+		for {
+			_, claimed, err := s.claimDue(ctx, benchNow)
+			if err != nil {
+				b.Fatal(err)
+			}
+			if claimed < s.dispatchBatch {
+				break
+			}
+		}
+	}
+	b.ReportMetric(float64(b.Elapsed().Nanoseconds())/float64(b.N*len(subs)), "ns/sub")
+}
@@ -0,0 +1,50 @@
+package bot
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ViolettaBykova/viot-tg-sirius/pkg/i18n"
+	"github.com/ViolettaBykova/viot-tg-sirius/pkg/weather"
+)
+
+var (
+	ErrCityNotSet             = errors.New("city is not set")
+	ErrAirQualityNotAvailable = errors.New("air quality is not available")
+	ErrSubscriptionNotFound   = errors.New("subscription not found")
+	ErrInvalidSchedule        = errors.New("invalid schedule")
+	ErrScheduleTooFrequent    = errors.New("schedule is too frequent")
+	ErrInvalidTimeOfDay       = errors.New("invalid time of day")
+	ErrTooManyTimes           = errors.New("too many notification times")
+	ErrInvalidTimezone        = errors.New("invalid timezone")
+	ErrInvalidPause           = errors.New("invalid pause duration")
+	ErrInvalidQuietHours      = errors.New("invalid quiet hours")
+	ErrInvalidThresholds      = errors.New("invalid change thresholds")
+)
+
+// UserMessage возвращает понятное пользователю описание ошибки получения погоды на языке lang
+func UserMessage(lang i18n.Lang, err error) string {
+	switch {
+	case errors.Is(err, ErrCityNotSet):
+		return i18n.T(lang, "error.city_not_set")
+	case errors.Is(err, ErrAirQualityNotAvailable):
+		return i18n.T(lang, "error.air_unavailable")
+	case errors.Is(err, weather.ErrCityNotFound):
+		return i18n.T(lang, "error.city_not_found")
+	case errors.Is(err, weather.ErrQuotaExceeded):
+		return i18n.T(lang, "error.quota")
+	case errors.Is(err, weather.ErrInvalidAPIKey):
+		return i18n.T(lang, "error.api_key")
+	case errors.Is(err, weather.ErrUnavailable), errors.Is(err, context.DeadlineExceeded):
+		return i18n.T(lang, "error.unavailable")
+	case errors.Is(err, weather.ErrDecode):
+		return i18n.T(lang, "error.decode")
+	default:
+		return i18n.T(lang, "error.generic")
+	}
+}
+
+// NeedsCity сообщает, что ошибку можно исправить только вводом другого города
+func NeedsCity(err error) bool {
+	return errors.Is(err, ErrCityNotSet) || errors.Is(err, weather.ErrCityNotFound)
+}
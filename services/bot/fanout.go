@@ -0,0 +1,150 @@
+package bot
+
+import (
+	"context"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ViolettaBykova/viot-tg-sirius/models"
+	"github.com/ViolettaBykova/viot-tg-sirius/pkg/weather"
+)
+
+// DefaultDispatchWorkers — сколько уведомлений по умолчанию отправляется одновременно
+const DefaultDispatchWorkers = 8
+
+// FanOutStats — счетчики рассылки с группировкой по месту с момента запуска.
+// Погода для группы берется через кэш, поэтому к провайдеру уходит не больше одного запроса
+// на группу; сколько запросов ушло на самом деле, показывает статистика кэша.
+type FanOutStats struct {
+	Updates int64 // Уведомлений, для которых нужна погода
+	Groups  int64 // Групп уведомлений в одно место на одном языке
+	Saved   int64 // Запросов погоды, которых удалось избежать группировкой
+}
+
+// fanOutStats — счетчики одного прохода рассылки
+type fanOutStats struct {
+	updates int
+	groups  int
+}
+
+func (st *fanOutStats) add(other fanOutStats) {
+	st.updates += other.updates
+	st.groups += other.groups
+}
+
+// fanOutCounters — накопленные счетчики рассылки
+type fanOutCounters struct {
+	updates atomic.Int64
+	groups  atomic.Int64
+}
+
+// updateGroup — уведомления в одно место на одном языке, погода для них запрашивается один раз
+type updateGroup struct {
+	place   weather.Place
+	updates []*pendingUpdate
+}
+
+// pendingUpdate — уведомление, которое нужно отправить, и параметры его отправки
+type pendingUpdate struct {
+	delivery *models.Delivery
+	options  []interface{}
+	weather  *weather.Conditions
+	err      error
+}
+
+// FanOutStats возвращает счетчики рассылки с группировкой по месту
+func (s *Service) FanOutStats() FanOutStats {
+	updates, groups := s.fanOut.updates.Load(), s.fanOut.groups.Load()
+	return FanOutStats{Updates: updates, Groups: groups, Saved: updates - groups}
+}
+
+// fanOutDeliveries отправляет пачку уведомлений, запрашивая погоду один раз на каждое место и язык.
+// Запросы погоды и отправки выполняются параллельно, но не больше чем dispatchWorkers одновременно.
+func (s *Service) fanOutDeliveries(ctx context.Context, deliveries []models.Delivery) fanOutStats {
+	now := time.Now()
+	groups := make(map[string]*updateGroup)
+	var order []*updateGroup
+	var updates []*pendingUpdate
+	for i := range deliveries {
+		d := &deliveries[i]
+		options, err := s.updateOptions(ctx, d.Subscription, now)
+		if err != nil {
+			s.recordAttempt(ctx, d, nil, err)
+			continue
+		}
+
+		place := placeOf(subscriptionUser(d.Subscription))
+		key := place.Lang + ":" + place.Key()
+		group, ok := groups[key]
+		if !ok {
+			group = &updateGroup{place: place}
+			groups[key] = group
+			order = append(order, group)
+		}
+		u := &pendingUpdate{delivery: d, options: options}
+		group.updates = append(group.updates, u)
+		updates = append(updates, u)
+	}
+
+	s.parallel(len(order), func(i int) {
+		group := order[i]
+		fetchCtx, cancel := context.WithTimeout(ctx, weatherRequestTimeout)
+		defer cancel()
+		conditions, err := s.weatherAPI.Current(fetchCtx, group.place)
+		if err != nil {
+			log.Printf("Ошибка при получении данных о погоде: %v", err)
+		}
+		for _, u := range group.updates {
+			u.err = err
+			if err == nil {
+				u.weather = withUserCity(conditions, subscriptionUser(u.delivery.Subscription))
+			}
+		}
+	})
+
+	s.parallel(len(updates), func(i int) {
+		u := updates[i]
+		if u.err != nil {
+			s.recordAttempt(ctx, u.delivery, nil, u.err)
+			return
+		}
+		if !s.stillSending(ctx, u.delivery) {
+			return
+		}
+		msg, err := s.sendConditions(ctx, u.delivery.Subscription, u.delivery.Header, u.weather, u.options)
+		s.recordAttempt(ctx, u.delivery, msg, err)
+	})
+
+	stats := fanOutStats{updates: len(updates), groups: len(order)}
+	s.fanOut.updates.Add(int64(stats.updates))
+	s.fanOut.groups.Add(int64(stats.groups))
+	return stats
+}
+
+// parallel вызывает fn для индексов от 0 до n, не больше чем в dispatchWorkers горутинах одновременно
+func (s *Service) parallel(n int, fn func(i int)) {
+	sem := make(chan struct{}, s.dispatchWorkers)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(i int) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			fn(i)
+		}(i)
+	}
+	wg.Wait()
+}
+
+// logFanOut записывает в лог, сколько запросов погоды сэкономила группировка за проход рассылки
+func (s *Service) logFanOut(stats *fanOutStats) {
+	if saved := stats.updates - stats.groups; saved > 0 {
+		log.Printf("Рассылка: уведомлений %d, групп по месту %d, сэкономлено запросов погоды %d",
+			stats.updates, stats.groups, saved)
+	}
+}
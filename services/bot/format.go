@@ -0,0 +1,81 @@
+package bot
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ViolettaBykova/viot-tg-sirius/models/units"
+	"github.com/ViolettaBykova/viot-tg-sirius/pkg/i18n"
+	"github.com/ViolettaBykova/viot-tg-sirius/pkg/weather"
+)
+
+var conditionEmoji = map[weather.Condition]string{
+	weather.ConditionClear:        "☀️",
+	weather.ConditionPartlyCloudy: "🌤",
+	weather.ConditionCloudy:       "☁️",
+	weather.ConditionFog:          "🌫",
+	weather.ConditionDrizzle:      "🌦",
+	weather.ConditionRain:         "🌧",
+	weather.ConditionSnow:         "🌨",
+	weather.ConditionThunderstorm: "⛈",
+}
+
+// formatConditions формирует многострочный отчет о текущей погоде в единицах u на языке lang
+func formatConditions(c *weather.Conditions, u units.Units, lang i18n.Lang) string {
+	emoji, ok := conditionEmoji[c.Condition]
+	if !ok {
+		emoji = "🌡"
+	}
+
+	var sb strings.Builder
+	sb.WriteString(i18n.T(lang, "report.title", emoji, c.City, c.Description) + "\n\n")
+	sb.WriteString(i18n.T(lang, "report.temp", formatTemp(c.Temp, u), formatTemp(c.FeelsLike, u)) + "\n")
+	sb.WriteString(i18n.T(lang, "report.humidity", c.Humidity) + "\n")
+
+	force := weather.Beaufort(c.WindSpeed)
+	compass := i18n.T(lang, fmt.Sprintf("compass.%d", weather.CompassSector(c.WindDeg)))
+	sb.WriteString(i18n.T(lang, "report.wind", compass, formatSpeed(c.WindSpeed, u, lang)))
+	if c.WindGust > c.WindSpeed {
+		sb.WriteString(i18n.T(lang, "report.gust", formatSpeed(c.WindGust, u, lang)))
+	}
+	sb.WriteString(i18n.T(lang, "report.beaufort", force, i18n.T(lang, fmt.Sprintf("beaufort.%d", force))) + "\n")
+
+	pressure := fmt.Sprintf("%.*f %s", u.PressurePrecision(), u.Pressure(c.Pressure), i18n.T(lang, "unit."+u.PressureUnit()))
+	sb.WriteString(i18n.T(lang, "report.pressure", pressure) + "\n")
+	sb.WriteString(i18n.T(lang, "report.clouds", c.Clouds) + "\n")
+	// Провайдер может не сообщить видимость, тогда вместо нее приходит 0
+	if c.Visibility > 0 {
+		sb.WriteString(i18n.T(lang, "report.visibility", float64(c.Visibility)/1000) + "\n")
+	}
+	if !c.Sunrise.IsZero() && !c.Sunset.IsZero() {
+		sb.WriteString(i18n.T(lang, "report.sun", c.Sunrise.Format("15:04"), c.Sunset.Format("15:04")) + "\n")
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+// formatTemp форматирует температуру в °C в единицах u, например "12.5°C"
+func formatTemp(celsius float64, u units.Units) string {
+	return fmt.Sprintf("%.1f%s", u.Temp(celsius), u.TempUnit())
+}
+
+// formatSpeed форматирует скорость в м/с в единицах u, например "3.2 м/с"
+func formatSpeed(ms float64, u units.Units, lang i18n.Lang) string {
+	return fmt.Sprintf("%.1f %s", u.Speed(ms), i18n.T(lang, "unit."+u.SpeedUnit()))
+}
+
+// aqiName возвращает словесное описание индекса качества воздуха на языке lang
+func aqiName(lang i18n.Lang, aqi int) string {
+	if aqi < 0 || aqi > MaxAQI {
+		aqi = 0
+	}
+	return i18n.T(lang, fmt.Sprintf("aqi.%d", aqi))
+}
+
+// formatShort формирует отчет о текущей погоде в одну строку для краткого вида подписки
+func formatShort(c *weather.Conditions, u units.Units, lang i18n.Lang) string {
+	emoji, ok := conditionEmoji[c.Condition]
+	if !ok {
+		emoji = "🌡"
+	}
+	return i18n.T(lang, "report.short", emoji, c.City, formatTemp(c.Temp, u), c.Description, formatSpeed(c.WindSpeed, u, lang))
+}
@@ -0,0 +1,79 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"github.com/ViolettaBykova/viot-tg-sirius/models"
+	tb "gopkg.in/tucnak/telebot.v2"
+)
+
+// inactiveReason определяет по ошибке отправки, что бот больше не может писать пользователю.
+// Пустой результат означает, что ошибка временная или не связана с пользователем.
+func inactiveReason(err error) models.InactiveReason {
+	switch {
+	case errors.Is(err, tb.ErrBlockedByUser):
+		return models.InactiveBlocked
+	case errors.Is(err, tb.ErrUserIsDeactivated):
+		return models.InactiveDeactivated
+	case errors.Is(err, tb.ErrChatNotFound):
+		return models.InactiveChatNotFound
+	case errors.Is(err, tb.ErrNotStartedByUser):
+		return models.InactiveNotStarted
+	}
+	return ""
+}
+
+// deactivateOnError отключает пользователя, если ошибка отправки означает, что бот больше не может ему писать.
+// Подписки отключенного пользователя не выбираются диспетчером, пока он снова не отправит /start.
+// Возвращает true, если пользователь отключен.
+func (s *Service) deactivateOnError(ctx context.Context, telegramID int64, err error) bool {
+	reason := inactiveReason(err)
+	if reason == "" {
+		return false
+	}
+	if err := s.setInactive(ctx, telegramID, reason); err != nil {
+		log.Printf("Failed to deactivate user %d: %v", telegramID, err)
+		return true
+	}
+	log.Printf("Пользователь %d отключен: %s", telegramID, reason)
+	return true
+}
+
+// Reactivate снова включает пользователя, отключенного из-за ошибок отправки, и заново планирует его подписки.
+// Пользователь, отправивший /start, снова доступен боту.
+func (s *Service) Reactivate(ctx context.Context, telegramID int64) error {
+	user, err := s.getUser(ctx, telegramID)
+	if err != nil {
+		return err
+	}
+	if user.Active() {
+		return nil
+	}
+	if err := s.setInactive(ctx, telegramID, ""); err != nil {
+		log.Printf("Failed to reactivate user %d: %v", telegramID, err)
+		return err
+	}
+	log.Printf("Пользователь %d снова активен, был отключен: %s", telegramID, user.InactiveReason)
+
+	if user.Stopped {
+		return nil
+	}
+	// Пока пользователь был отключен, время подписок устарело, поэтому оно рассчитывается заново
+	return s.rescheduleEnabled(ctx, user)
+}
+
+func (s *Service) setInactive(ctx context.Context, telegramID int64, reason models.InactiveReason) error {
+	ctx, err := s.store.CtxWithTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = s.store.TxRollback(ctx)
+	}()
+	if err := s.store.SetInactive(ctx, telegramID, reason); err != nil {
+		return err
+	}
+	return s.store.TxCommit(ctx)
+}
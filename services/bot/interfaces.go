@@ -2,9 +2,13 @@ package bot
 
 import (
 	"context"
+	"time"
 
 	"github.com/ViolettaBykova/viot-tg-sirius/models"
 	"github.com/ViolettaBykova/viot-tg-sirius/models/scenes"
+	"github.com/ViolettaBykova/viot-tg-sirius/models/units"
+	"github.com/ViolettaBykova/viot-tg-sirius/pkg/i18n"
+	tb "gopkg.in/tucnak/telebot.v2"
 )
 
 type (
@@ -23,7 +27,44 @@ type (
 		GetUser(ctx context.Context, telegramID int64) (*models.User, error)
 		UpdateUserScene(ctx context.Context, telegramID int64, scene scenes.Scene) error
 		SetCity(ctx context.Context, telegramID int64, city string) error
-		SetUpdateInterval(ctx context.Context, telegramID int64, interval string) error
-		GetAllUsersWithInterval(ctx context.Context) ([]models.User, error)
+		SetLocation(ctx context.Context, telegramID int64, city string, lat, lon float64) error
+		SetUnits(ctx context.Context, telegramID int64, u units.Units) error
+		SetLanguage(ctx context.Context, telegramID int64, lang i18n.Lang) error
+		SetTimezone(ctx context.Context, telegramID int64, timezone string, auto bool) error
+		SetShowAirQuality(ctx context.Context, telegramID int64, show bool) error
+		SetAQIAlertLevel(ctx context.Context, telegramID int64, level int) error
+		SetPause(ctx context.Context, telegramID int64, paused bool, until *time.Time) error
+		SetStopped(ctx context.Context, telegramID int64, stopped bool) error
+		SetInactive(ctx context.Context, telegramID int64, reason models.InactiveReason) error
+		SetQuietHours(ctx context.Context, telegramID int64, from, to string) error
+		SetQuietMode(ctx context.Context, telegramID int64, mode models.QuietMode) error
+		SetQuietMissed(ctx context.Context, telegramID int64, missed bool) error
+		GetQuietMissedUsers(ctx context.Context) ([]models.User, error)
+		TryAdvisoryLock(ctx context.Context, key int64) (bool, error)
+		AdvisoryUnlock(ctx context.Context, key int64) error
+		CreateSubscription(ctx context.Context, sub *models.Subscription) error
+		GetSubscription(ctx context.Context, id int64) (*models.Subscription, error)
+		GetUserSubscriptions(ctx context.Context, telegramID int64) ([]models.Subscription, error)
+		GetEnabledSubscriptions(ctx context.Context) ([]models.Subscription, error)
+		GetUnscheduledSubscriptions(ctx context.Context) ([]models.Subscription, error)
+		ClaimDueSubscriptions(ctx context.Context, now time.Time, limit int) ([]models.Subscription, error)
+		SetNextRuns(ctx context.Context, subs []models.Subscription) error
+		UpdateSubscription(ctx context.Context, sub *models.Subscription, columns ...string) error
+		DeleteSubscription(ctx context.Context, telegramID, id int64) error
+		CreateDeliveries(ctx context.Context, deliveries []models.Delivery) error
+		ClaimDeliveries(ctx context.Context, now time.Time, limit int) ([]models.Delivery, error)
+		UpdateDeliveries(ctx context.Context, deliveries []models.Delivery, columns ...string) error
+		UpdateDelivery(ctx context.Context, d *models.Delivery, columns ...string) (bool, error)
+		RenewDeliveries(ctx context.Context, ids []int64, now time.Time) (int, error)
+		AbandonDeliveries(ctx context.Context, before time.Time, reason string) (int, error)
+		DeleteFinishedDeliveries(ctx context.Context, before time.Time, limit int) (int, error)
+		ClaimAlert(ctx context.Context, alertID string, telegramID int64, expiresAt time.Time) (bool, error)
+		ReleaseAlert(ctx context.Context, alertID string, telegramID int64) error
+		DeleteExpiredAlerts(ctx context.Context, before time.Time) error
+	}
+
+	// Messenger отправляет сообщения пользователям, например через очередь с учетом ограничений Telegram
+	Messenger interface {
+		Send(to tb.Recipient, what interface{}, options ...interface{}) (*tb.Message, error)
 	}
 )
@@ -0,0 +1,72 @@
+package bot
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// DefaultLeaderLockKey — ключ advisory-блокировки ведущего экземпляра по умолчанию
+const DefaultLeaderLockKey int64 = 0x77656174686572 // "weather"
+
+// DefaultLeaderInterval — как часто по умолчанию проверять блокировку ведущего
+const DefaultLeaderInterval = 15 * time.Second
+
+// runElection раз в leaderInterval пытается взять блокировку ведущего. Ведущий запускает cron с периодическими
+// задачами сервиса, на остальных экземплярах cron остановлен.
+// Если ведущий упал или потерял связь с базой, Postgres снимает блокировку и ее берет другой экземпляр.
+func (s *Service) runElection(ctx context.Context) {
+	defer close(s.electionDone)
+
+	ticker := time.NewTicker(s.leaderInterval)
+	defer ticker.Stop()
+	for {
+		s.elect(ctx)
+		select {
+		case <-ctx.Done():
+			s.resign()
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Service) elect(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, s.leaderInterval)
+	defer cancel()
+
+	held, err := s.store.TryAdvisoryLock(ctx, s.leaderKey)
+	if err != nil {
+		// Без связи с базой нельзя быть уверенным, что блокировка еще наша
+		log.Printf("Ошибка проверки блокировки ведущего: %v", err)
+		held = false
+	}
+
+	switch {
+	case held && !s.leader.Load():
+		log.Printf("Экземпляр стал ведущим, запускаю планировщик")
+		s.leader.Store(true)
+		s.cron.Start()
+	case !held && s.leader.Load():
+		log.Printf("Экземпляр перестал быть ведущим, останавливаю планировщик")
+		s.demote()
+	}
+}
+
+// demote останавливает cron, дожидаясь уже запущенных задач
+func (s *Service) demote() {
+	s.leader.Store(false)
+	<-s.cron.Stop().Done()
+}
+
+// resign снимает с экземпляра роль ведущего при остановке, чтобы другой экземпляр подхватил задачи сразу
+func (s *Service) resign() {
+	if s.leader.Load() {
+		s.demote()
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), s.leaderInterval)
+	defer cancel()
+	if err := s.store.AdvisoryUnlock(ctx, s.leaderKey); err != nil {
+		log.Printf("Ошибка снятия блокировки ведущего: %v", err)
+	}
+}
@@ -0,0 +1,105 @@
+package bot
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/ViolettaBykova/viot-tg-sirius/storage/postgres"
+	"github.com/robfig/cron/v3"
+)
+
+// TestLeaderElection запускает два экземпляра сервиса с одной базой: ведущим должен стать ровно один,
+// а после потери им связи с базой роль должен подхватить второй. Нужна база из DATABASE_ADDR.
+func TestLeaderElection(t *testing.T) {
+	if os.Getenv("DATABASE_ADDR") == "" {
+		t.Skip("DATABASE_ADDR не задан")
+	}
+
+	const interval = time.Second
+	// Свой ключ блокировки, чтобы не мешать запущенному боту с той же базой
+	key := time.Now().UnixNano()
+
+	first, firstStore := startElection(t, key, interval)
+	second, secondStore := startElection(t, key, interval)
+
+	waitFor(t, 2*interval, "ни один экземпляр не стал ведущим", func() bool {
+		return first.leader.Load() || second.leader.Load()
+	})
+	// Несколько проверок подряд роль не должна переходить и не должна достаться обоим
+	for range 3 {
+		if first.leader.Load() == second.leader.Load() {
+			t.Fatalf("ведущих экземпляров должно быть ровно один: first=%v second=%v", first.leader.Load(), second.leader.Load())
+		}
+		time.Sleep(interval)
+	}
+
+	leader, leaderStore, follower, followerStore := first, firstStore, second, secondStore
+	if second.leader.Load() {
+		leader, leaderStore, follower, followerStore = second, secondStore, first, firstStore
+	}
+
+	// Ведущий теряет связь с базой: новых соединений у него нет, а соединение с блокировкой обрывается
+	_ = leaderStore.Close()
+	db, err := followerStore.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var terminated bool
+	err = db.QueryRowContext(context.Background(), `
+        SELECT pg_terminate_backend(pid) FROM pg_locks
+        WHERE locktype = 'advisory' AND granted AND objsubid = 1
+            AND ((classid::bigint << 32) | objid::bigint) = $1`, key).Scan(&terminated)
+	if err != nil || !terminated {
+		t.Fatalf("не удалось оборвать соединение ведущего: terminated=%v err=%v", terminated, err)
+	}
+
+	waitFor(t, interval+interval/2, "второй экземпляр не стал ведущим", follower.leader.Load)
+	waitFor(t, interval+interval/2, "прежний ведущий не сложил роль", func() bool {
+		return !leader.leader.Load()
+	})
+}
+
+// startElection создает сервис с собственным подключением к базе и запускает для него выбор ведущего
+func startElection(t *testing.T, key int64, interval time.Duration) (*Service, *postgres.Storage) {
+	t.Helper()
+
+	store, err := postgres.New(
+		os.Getenv("DATABASE_ADDR"),
+		os.Getenv("DATABASE_NAME"),
+		os.Getenv("DATABASE_USER"),
+		os.Getenv("DATABASE_PASSWORD"),
+		2,
+		"test",
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := New(store, nil, cron.New(), nil, nil, WithLeaderElection(key, interval))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.stopElection = cancel
+	s.electionDone = make(chan struct{})
+	go s.runElection(ctx)
+
+	t.Cleanup(func() {
+		s.stopElection()
+		<-s.electionDone
+		_ = store.Close()
+	})
+	return s, store
+}
+
+// waitFor ждет, пока ok не вернет true, но не дольше timeout
+func waitFor(t *testing.T, timeout time.Duration, msg string, ok func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(timeout)
+	for !ok() {
+		if time.Now().After(deadline) {
+			t.Fatal(msg)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
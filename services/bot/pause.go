@@ -0,0 +1,191 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// pausePrefixes — слова, с которых может начинаться срок паузы: "до понедельника", "на 2 часа", "for 3 days"
+var pausePrefixes = []string{"до", "на", "until", "till", "for"}
+
+// pauseDays — относительные дни для срока паузы, значение — сколько дней прибавить к сегодняшнему
+var pauseDays = map[string]int{
+	"сегодня": 0, "today": 0,
+	"завтра": 1, "tomorrow": 1,
+	"послезавтра": 2,
+}
+
+// parsePause разбирает срок паузы относительно now и возвращает момент ее окончания в часовом поясе now.
+// Пустой текст — пауза без срока, до команды /resume. Поддерживаются промежутки ("2 часа", "3 days"),
+// время ("до 18:00"), дни ("до понедельника", "until tomorrow 9:00") и даты ("до 20.10", "until 2026-10-20").
+// Окончание паузы в день без времени — начало этого дня.
+func parsePause(text string, now time.Time) (*time.Time, error) {
+	text = strings.ToLower(strings.Join(strings.Fields(text), " "))
+	if text == "" {
+		return nil, nil
+	}
+	for _, word := range pausePrefixes {
+		if rest, ok := strings.CutPrefix(text, word+" "); ok {
+			text = rest
+			break
+		}
+	}
+
+	if d, ok := parseDuration(text); ok {
+		until := now.Add(d)
+		return &until, nil
+	}
+
+	// Последнее слово может быть временем: "понедельника 9:00", "tomorrow at 8".
+	// Точка обозначает дату ("20.10"), поэтому время без двоеточия принимается только после "в" или "at".
+	words := strings.Fields(text)
+	clock := ""
+	if n := len(words); strings.Contains(words[n-1], ":") ||
+		n > 1 && (words[n-2] == "в" || words[n-2] == "at") && !strings.Contains(words[n-1], ".") {
+		clock, words = words[n-1], words[:n-1]
+	}
+	if n := len(words); n > 0 && (words[n-1] == "в" || words[n-1] == "at") {
+		words = words[:n-1]
+	}
+	day := strings.Join(words, " ")
+
+	var tod TimeOfDay
+	if clock != "" {
+		var err error
+		if tod, err = parseTimeOfDay(clock); err != nil {
+			return nil, fmt.Errorf("%q: %w", text, ErrInvalidPause)
+		}
+	}
+
+	date, ok := pauseDate(day, now)
+	if !ok {
+		return nil, fmt.Errorf("%q: %w", text, ErrInvalidPause)
+	}
+	until := time.Date(date.Year(), date.Month(), date.Day(), tod.Hour, tod.Minute, 0, 0, now.Location())
+	if day == "" && !until.After(now) {
+		// Только время: ближайшее такое время, сегодня или завтра
+		until = until.AddDate(0, 0, 1)
+	}
+	if !until.After(now) {
+		return nil, fmt.Errorf("%q is in the past: %w", text, ErrInvalidPause)
+	}
+	return &until, nil
+}
+
+// pauseDate разбирает день окончания паузы: пустой — сегодня, "завтра", день недели (ближайший после сегодня)
+// или дата в виде ДД.ММ, ДД.ММ.ГГГГ или ГГГГ-ММ-ДД
+func pauseDate(text string, now time.Time) (time.Time, bool) {
+	if text == "" {
+		return now, true
+	}
+	if n, ok := pauseDays[text]; ok {
+		return now.AddDate(0, 0, n), true
+	}
+	if day, ok := parseWeekday(text); ok {
+		n := (int(day)-int(now.Weekday())+6)%7 + 1
+		return now.AddDate(0, 0, n), true
+	}
+	if date, err := time.ParseInLocation("2006-01-02", text, now.Location()); err == nil {
+		return date, true
+	}
+	if date, err := time.ParseInLocation("02.01.2006", text, now.Location()); err == nil {
+		return date, true
+	}
+	if date, err := time.ParseInLocation("2.1", text, now.Location()); err == nil {
+		// Дата без года — ближайшая такая дата, начиная с сегодняшней
+		date = date.AddDate(now.Year(), 0, 0)
+		if date.Before(time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())) {
+			date = date.AddDate(1, 0, 0)
+		}
+		return date, true
+	}
+	return time.Time{}, false
+}
+
+// Pause приостанавливает уведомления пользователя на срок, заданный текстом (см. parsePause).
+// Возвращает окончание паузы по местному времени пользователя, nil — пауза до команды /resume.
+func (s *Service) Pause(ctx context.Context, telegramID int64, text string) (*time.Time, error) {
+	user, err := s.getUser(ctx, telegramID)
+	if err != nil {
+		return nil, err
+	}
+	loc, err := LoadTimezone(user.Timezone)
+	if err != nil {
+		return nil, err
+	}
+	until, err := parsePause(text, time.Now().In(loc))
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, err = s.store.CtxWithTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = s.store.TxRollback(ctx)
+	}()
+	if err := s.store.SetPause(ctx, telegramID, true, until); err != nil {
+		log.Printf("Failed to pause user %d: %v", telegramID, err)
+		return nil, err
+	}
+	return until, s.store.TxCommit(ctx)
+}
+
+// Resume снимает паузу и возобновляет уведомления, остановленные командой /stop
+func (s *Service) Resume(ctx context.Context, telegramID int64) error {
+	user, err := s.getUser(ctx, telegramID)
+	if err != nil {
+		return err
+	}
+
+	ctx, err = s.store.CtxWithTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = s.store.TxRollback(ctx)
+	}()
+	if err := s.store.SetPause(ctx, telegramID, false, nil); err != nil {
+		log.Printf("Failed to resume user %d: %v", telegramID, err)
+		return err
+	}
+	if err := s.store.SetStopped(ctx, telegramID, false); err != nil {
+		log.Printf("Failed to resume user %d: %v", telegramID, err)
+		return err
+	}
+	if err := s.store.TxCommit(ctx); err != nil {
+		return err
+	}
+
+	if !user.Stopped && !user.Paused {
+		return nil
+	}
+	// Пока уведомления были остановлены или на паузе, диспетчер не выбирал подписки и их время устарело,
+	// поэтому оно рассчитывается заново
+	return s.rescheduleEnabled(ctx, user)
+}
+
+// Stop останавливает все уведомления пользователя: диспетчер не выбирает подписки остановленных пользователей.
+// Подписки сохраняются и снова начинают работать после /resume.
+func (s *Service) Stop(ctx context.Context, telegramID int64) error {
+	ctx, err := s.store.CtxWithTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = s.store.TxRollback(ctx)
+	}()
+	if err := s.store.SetStopped(ctx, telegramID, true); err != nil {
+		log.Printf("Failed to stop user %d: %v", telegramID, err)
+		return err
+	}
+	if err := s.store.SetPause(ctx, telegramID, false, nil); err != nil {
+		log.Printf("Failed to stop user %d: %v", telegramID, err)
+		return err
+	}
+	return s.store.TxCommit(ctx)
+}
@@ -0,0 +1,222 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/ViolettaBykova/viot-tg-sirius/models"
+	"github.com/ViolettaBykova/viot-tg-sirius/pkg/i18n"
+	tb "gopkg.in/tucnak/telebot.v2"
+)
+
+// quietOff — слова, которыми выключаются тихие часы
+var quietOff = map[string]bool{"off": true, "выкл": true, "выключить": true, "нет": true, "no": true}
+
+// quietSeparators — разделители начала и конца тихих часов: "23:00-07:00", "с 23 до 7", "from 23:00 to 7:00"
+var quietSeparators = []string{" до ", " to ", "-", "–", "—", " "}
+
+// parseQuietHours разбирает тихие часы. off — пользователь выключает их.
+func parseQuietHours(text string) (from, to TimeOfDay, off bool, err error) {
+	text = strings.ToLower(strings.Join(strings.Fields(text), " "))
+	if quietOff[text] {
+		return TimeOfDay{}, TimeOfDay{}, true, nil
+	}
+	for _, word := range []string{"с", "from"} {
+		text = strings.TrimPrefix(text, word+" ")
+	}
+
+	for _, sep := range quietSeparators {
+		first, second, ok := strings.Cut(text, sep)
+		if !ok {
+			continue
+		}
+		if from, err = parseTimeOfDay(strings.TrimSpace(first)); err != nil {
+			break
+		}
+		if to, err = parseTimeOfDay(strings.TrimSpace(second)); err != nil {
+			break
+		}
+		if from == to {
+			break
+		}
+		return from, to, false, nil
+	}
+	return TimeOfDay{}, TimeOfDay{}, false, fmt.Errorf("%q: %w", text, ErrInvalidQuietHours)
+}
+
+// inQuietHours сообщает, приходится ли момент now на тихие часы пользователя по его местному времени.
+// Тихие часы могут переходить через полночь, например с 23:00 до 07:00.
+func inQuietHours(user *models.User, now time.Time) bool {
+	if user.QuietFrom == "" || user.QuietTo == "" {
+		return false
+	}
+	from, err := parseTimeOfDay(user.QuietFrom)
+	if err != nil {
+		return false
+	}
+	to, err := parseTimeOfDay(user.QuietTo)
+	if err != nil {
+		return false
+	}
+	loc, err := LoadTimezone(user.Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+
+	local := now.In(loc)
+	minute := local.Hour()*60 + local.Minute()
+	start, end := from.Hour*60+from.Minute, to.Hour*60+to.Minute
+	if start < end {
+		return minute >= start && minute < end
+	}
+	return minute >= start || minute < end
+}
+
+// GetQuietHours возвращает тихие часы пользователя. Пустые from и to — тихие часы выключены.
+func (s *Service) GetQuietHours(ctx context.Context, telegramID int64) (from, to string, mode models.QuietMode, err error) {
+	user, err := s.getUser(ctx, telegramID)
+	if err != nil {
+		return "", "", "", err
+	}
+	return user.QuietFrom, user.QuietTo, user.QuietMode, nil
+}
+
+// SetQuietHours задает тихие часы по местному времени пользователя, например "23:00-07:00", или выключает их ("off").
+// Возвращает сохраненные начало и конец, пустые — тихие часы выключены.
+func (s *Service) SetQuietHours(ctx context.Context, telegramID int64, text string) (string, string, error) {
+	from, to, off, err := parseQuietHours(text)
+	if err != nil {
+		return "", "", err
+	}
+	fromText, toText := from.String(), to.String()
+	if off {
+		fromText, toText = "", ""
+	}
+
+	ctx, err = s.store.CtxWithTx(ctx)
+	if err != nil {
+		return "", "", err
+	}
+	defer func() {
+		_ = s.store.TxRollback(ctx)
+	}()
+	if err := s.store.SetQuietHours(ctx, telegramID, fromText, toText); err != nil {
+		log.Printf("Failed to set quiet hours for user %d: %v", telegramID, err)
+		return "", "", err
+	}
+	return fromText, toText, s.store.TxCommit(ctx)
+}
+
+// SetQuietMode задает, пропускать ли обновления в тихие часы или отправлять их без звука
+func (s *Service) SetQuietMode(ctx context.Context, telegramID int64, mode models.QuietMode) error {
+	if mode != models.QuietSkip && mode != models.QuietSilent {
+		return fmt.Errorf("unknown quiet mode %q", mode)
+	}
+	ctx, err := s.store.CtxWithTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = s.store.TxRollback(ctx)
+	}()
+	if err := s.store.SetQuietMode(ctx, telegramID, mode); err != nil {
+		log.Printf("Failed to set quiet mode for user %d: %v", telegramID, err)
+		return err
+	}
+	return s.store.TxCommit(ctx)
+}
+
+// StartQuietHoursSummaries планирует проверку каждые interval: тем, у кого закончились тихие часы
+// и были пропущены обновления, отправляется одна сводка
+func (s *Service) StartQuietHoursSummaries(interval time.Duration) error {
+	_, err := s.cron.AddFunc(fmt.Sprintf("@every %s", interval), func() {
+		s.sendQuietSummaries(context.Background())
+	})
+	return err
+}
+
+func (s *Service) sendQuietSummaries(ctx context.Context) {
+	users, err := s.quietMissedUsers(ctx)
+	if err != nil {
+		log.Printf("Ошибка при загрузке пользователей для сводки после тихих часов: %v", err)
+		return
+	}
+
+	now := time.Now()
+	for i := range users {
+		user := &users[i]
+		if inQuietHours(user, now) {
+			continue
+		}
+		if !user.Stopped && !user.IsPaused(now) {
+			s.sendQuietSummary(ctx, user)
+		}
+		if err := s.setQuietMissed(ctx, user.TelegramID, false); err != nil {
+			log.Printf("Ошибка при сбросе отметки о тихих часах пользователя %d: %v", user.TelegramID, err)
+		}
+	}
+}
+
+// sendQuietSummary отправляет одну сводку с текущей погодой по всем включенным подпискам пользователя
+func (s *Service) sendQuietSummary(ctx context.Context, user *models.User) {
+	subs, err := s.Subscriptions(ctx, user.TelegramID)
+	if err != nil {
+		log.Printf("Ошибка при загрузке подписок пользователя %d: %v", user.TelegramID, err)
+		return
+	}
+
+	lines := []string{i18n.T(user.Language, "quiet.summary")}
+	for i := range subs {
+		if !subs[i].Enabled {
+			continue
+		}
+		subs[i].User = user
+		subUser := subscriptionUser(&subs[i])
+		conditions, err := s.currentWeather(ctx, subUser)
+		if err != nil {
+			log.Printf("Ошибка при получении погоды для сводки по подписке %d: %v", subs[i].ID, err)
+			continue
+		}
+		lines = append(lines, formatShort(conditions, user.Units, user.Language))
+	}
+	if len(lines) == 1 {
+		return
+	}
+
+	if _, err := s.bot.Send(&tb.User{ID: user.TelegramID}, strings.Join(lines, "\n")); err != nil {
+		log.Printf("Ошибка отправки сводки после тихих часов пользователю %d: %v", user.TelegramID, err)
+		s.deactivateOnError(ctx, user.TelegramID, err)
+	}
+}
+
+func (s *Service) quietMissedUsers(ctx context.Context) ([]models.User, error) {
+	ctx, err := s.store.CtxWithTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = s.store.TxRollback(ctx)
+	}()
+	users, err := s.store.GetQuietMissedUsers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return users, s.store.TxCommit(ctx)
+}
+
+func (s *Service) setQuietMissed(ctx context.Context, telegramID int64, missed bool) error {
+	ctx, err := s.store.CtxWithTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = s.store.TxRollback(ctx)
+	}()
+	if err := s.store.SetQuietMissed(ctx, telegramID, missed); err != nil {
+		return err
+	}
+	return s.store.TxCommit(ctx)
+}
@@ -0,0 +1,620 @@
+package bot
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ViolettaBykova/viot-tg-sirius/pkg/i18n"
+	"github.com/robfig/cron/v3"
+)
+
+// Расписание уведомлений хранится в поле schedule подписки в одном из видов:
+//
+//	1h                   — код интервала из Intervals
+//	every 45m            — произвольный интервал
+//	at 07:30,19:00       — каждый день в указанное местное время
+//	at 08:00 on 1,2,3,4,5 — в указанные дни недели (0 — воскресенье)
+//	cron 0 8 * * 1-5     — выражение cron в часовом поясе пользователя
+const (
+	everyPrefix = "every "
+	dailyPrefix = "at "
+	daysSep     = " on "
+	cronPrefix  = "cron "
+)
+
+// MaxDailyTimes — сколько раз в день можно получать уведомления по времени суток
+const MaxDailyTimes = 6
+
+// DefaultMinInterval — минимальный промежуток между уведомлениями по умолчанию, равен самому частому из Intervals
+const DefaultMinInterval = 30 * time.Second
+
+// checkRuns — сколько ближайших срабатываний проверяется на минимальный промежуток
+const checkRuns = 7*MaxDailyTimes + 1
+
+// TimeOfDay — время суток по местному времени пользователя
+type TimeOfDay struct {
+	Hour   int
+	Minute int
+}
+
+func (t TimeOfDay) String() string {
+	return fmt.Sprintf("%02d:%02d", t.Hour, t.Minute)
+}
+
+// parseSchedule разбирает расписание, введенное пользователем, и возвращает его в виде для хранения.
+// Понимает названия интервалов из Intervals на любом языке, интервалы ("каждые 2 часа", "every 45m"),
+// время по дням недели ("по будням в 8:00", "mon,fri at 9:00", "в 7:30 и 19:00")
+// и выражения cron ("0 8 * * 1-5", "@daily"). Уведомления не могут приходить чаще minInterval.
+func parseSchedule(text string, minInterval time.Duration) (string, error) {
+	text = strings.Join(strings.Fields(text), " ")
+	if text == "" {
+		return "", ErrInvalidSchedule
+	}
+	lower := strings.ToLower(text)
+
+	schedule, err := parseScheduleText(text, lower)
+	if err != nil {
+		return "", err
+	}
+	if err := checkMinInterval(schedule, minInterval); err != nil {
+		return "", err
+	}
+	return schedule, nil
+}
+
+func parseScheduleText(text, lower string) (string, error) {
+	if code, ok := parsePreset(lower); ok {
+		return code, nil
+	}
+	if expr, ok := strings.CutPrefix(lower, "cron "); ok {
+		return parseCron(text[len(text)-len(expr):])
+	}
+	if expr, ok := strings.CutPrefix(lower, "@every "); ok {
+		return parseEvery(expr)
+	}
+	if strings.HasPrefix(lower, "@") || strings.HasPrefix(text, "CRON_TZ=") || strings.HasPrefix(text, "TZ=") {
+		return parseCron(text)
+	}
+	if cronFields.MatchString(lower) {
+		if schedule, err := parseCron(text); err == nil {
+			return schedule, nil
+		}
+	}
+	if days, times, ok := splitAt(lower); ok {
+		schedule, err := parseDaily(days, times)
+		if err == nil {
+			return schedule, nil
+		}
+		// "раз в 2 часа" тоже содержит предлог, но это интервал
+		if schedule, everyErr := parseEvery(lower); everyErr == nil {
+			return schedule, nil
+		}
+		return "", err
+	}
+	return parseEvery(lower)
+}
+
+// parsePreset возвращает код интервала по коду или его названию на любом из языков
+func parsePreset(text string) (string, bool) {
+	for _, code := range Intervals {
+		if text == code {
+			return code, true
+		}
+		for _, lang := range i18n.Supported {
+			if text == strings.ToLower(i18n.T(lang, "interval."+code)) {
+				return code, true
+			}
+		}
+	}
+	return "", false
+}
+
+// cronFields — пять полей cron из цифр, названий и символов * / , - ?
+var cronFields = regexp.MustCompile(`^[0-9a-z*/,?-]+( [0-9a-z*/,?-]+){4}$`)
+
+func parseCron(expr string) (string, error) {
+	if _, err := cron.ParseStandard(expr); err != nil {
+		return "", fmt.Errorf("%q: %w: %v", expr, ErrInvalidSchedule, err)
+	}
+	return cronPrefix + expr, nil
+}
+
+// everyWords — слова, с которых может начинаться интервал
+var everyWords = []string{"каждые", "каждый", "каждую", "каждое", "раз в", "every", "each"}
+
+// durationUnits — единицы интервала по-русски и по-английски
+var durationUnits = map[string]time.Duration{
+	"s": time.Second, "sec": time.Second, "secs": time.Second, "second": time.Second, "seconds": time.Second,
+	"с": time.Second, "сек": time.Second, "секунда": time.Second, "секунду": time.Second, "секунды": time.Second, "секунд": time.Second,
+	"m": time.Minute, "min": time.Minute, "mins": time.Minute, "minute": time.Minute, "minutes": time.Minute,
+	"м": time.Minute, "мин": time.Minute, "минута": time.Minute, "минуту": time.Minute, "минуты": time.Minute, "минут": time.Minute,
+	"h": time.Hour, "hr": time.Hour, "hrs": time.Hour, "hour": time.Hour, "hours": time.Hour,
+	"ч": time.Hour, "час": time.Hour, "часа": time.Hour, "часов": time.Hour,
+	"d": 24 * time.Hour, "day": 24 * time.Hour, "days": 24 * time.Hour,
+	"д": 24 * time.Hour, "день": 24 * time.Hour, "дня": 24 * time.Hour, "дней": 24 * time.Hour, "сутки": 24 * time.Hour,
+	"w": 7 * 24 * time.Hour, "week": 7 * 24 * time.Hour, "weeks": 7 * 24 * time.Hour,
+	"нед": 7 * 24 * time.Hour, "неделя": 7 * 24 * time.Hour, "неделю": 7 * 24 * time.Hour, "недели": 7 * 24 * time.Hour, "недель": 7 * 24 * time.Hour,
+}
+
+var durationPattern = regexp.MustCompile(`^(\d+)? ?(\pL+)$`)
+
+// maxEvery — самый длинный интервал между уведомлениями
+const maxEvery = 365 * 24 * time.Hour
+
+// parseEvery разбирает интервал: "2h30m", "каждые 2 часа", "every 45 minutes", "каждый час"
+func parseEvery(text string) (string, error) {
+	for _, word := range everyWords {
+		if rest, ok := strings.CutPrefix(text, word+" "); ok {
+			text = rest
+			break
+		}
+	}
+
+	d, ok := parseDuration(text)
+	if !ok || d%time.Second != 0 {
+		return "", fmt.Errorf("%q: %w", text, ErrInvalidSchedule)
+	}
+	return everyPrefix + formatDuration(d), nil
+}
+
+// parseDuration разбирает положительный промежуток не длиннее maxEvery: "2h", "1h30m", "3 часа", "45 minutes", "день"
+func parseDuration(text string) (time.Duration, bool) {
+	d, err := time.ParseDuration(strings.ReplaceAll(text, " ", ""))
+	if err != nil {
+		match := durationPattern.FindStringSubmatch(text)
+		if match == nil {
+			return 0, false
+		}
+		unit, ok := durationUnits[match[2]]
+		if !ok {
+			return 0, false
+		}
+		n := 1
+		if match[1] != "" {
+			// Число ограничивается до умножения, иначе большое n переполняет time.Duration
+			if n, err = strconv.Atoi(match[1]); err != nil || n > int(maxEvery/unit) {
+				return 0, false
+			}
+		}
+		d = time.Duration(n) * unit
+	}
+	return d, d > 0 && d <= maxEvery
+}
+
+// formatDuration возвращает интервал без нулевых частей: "2h", "1h30m", "45s"
+func formatDuration(d time.Duration) string {
+	s := d.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = strings.TrimSuffix(s, "0s")
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+	return s
+}
+
+// splitAt делит правило на дни и время по предлогу "в" или "at": "по будням в 8:00" → "по будням", "8:00"
+func splitAt(text string) (days, times string, ok bool) {
+	for _, word := range []string{"в", "at"} {
+		if rest, ok := strings.CutPrefix(text, word+" "); ok {
+			return "", rest, true
+		}
+		if i := strings.LastIndex(text, " "+word+" "); i >= 0 {
+			return text[:i], text[i+len(word)+2:], true
+		}
+	}
+	return "", "", false
+}
+
+// parseDaily разбирает правило по дням недели и времени суток
+func parseDaily(daysText, timesText string) (string, error) {
+	times, err := parseTimesOfDay(timesText)
+	if err != nil {
+		return "", err
+	}
+	days, err := parseWeekdays(daysText)
+	if err != nil {
+		return "", err
+	}
+	return dailySchedule(times, days), nil
+}
+
+// parseTimesOfDay разбирает список времени через пробел, запятую или союз, например "7:30 и 19:00".
+// Возвращает отсортированный список без повторов.
+func parseTimesOfDay(text string) ([]TimeOfDay, error) {
+	fields := strings.FieldsFunc(text, func(r rune) bool {
+		return r == ',' || r == ';' || r == ' '
+	})
+
+	times := make([]TimeOfDay, 0, len(fields))
+	for _, field := range fields {
+		if field == "и" || field == "and" {
+			continue
+		}
+		t, err := parseTimeOfDay(field)
+		if err != nil {
+			return nil, err
+		}
+		if !slices.Contains(times, t) {
+			times = append(times, t)
+		}
+	}
+	if len(times) == 0 {
+		return nil, fmt.Errorf("%q: %w", text, ErrInvalidTimeOfDay)
+	}
+	if len(times) > MaxDailyTimes {
+		return nil, ErrTooManyTimes
+	}
+	slices.SortFunc(times, func(a, b TimeOfDay) int {
+		return (a.Hour*60 + a.Minute) - (b.Hour*60 + b.Minute)
+	})
+	return times, nil
+}
+
+// parseTimeOfDay разбирает время в формате ЧЧ:ММ, Ч:ММ, ЧЧ.ММ или ЧЧ
+func parseTimeOfDay(s string) (TimeOfDay, error) {
+	hour, minute, found := strings.Cut(strings.ReplaceAll(s, ".", ":"), ":")
+	if !found {
+		minute = "0"
+	}
+	h, err := strconv.Atoi(hour)
+	if err != nil || h < 0 || h > 23 {
+		return TimeOfDay{}, fmt.Errorf("%q: %w", s, ErrInvalidTimeOfDay)
+	}
+	m, err := strconv.Atoi(minute)
+	if err != nil || m < 0 || m > 59 || (found && len(minute) != 2) {
+		return TimeOfDay{}, fmt.Errorf("%q: %w", s, ErrInvalidTimeOfDay)
+	}
+	return TimeOfDay{Hour: h, Minute: m}, nil
+}
+
+// weekdayGroups — слова, обозначающие несколько дней недели сразу; nil — все дни
+var weekdayGroups = map[string][]time.Weekday{
+	"будни": workdays, "будням": workdays, "weekday": workdays, "weekdays": workdays,
+	"выходные": weekends, "выходным": weekends, "weekend": weekends, "weekends": weekends,
+	"день": nil, "ежедневно": nil, "day": nil, "daily": nil,
+}
+
+var (
+	workdays = []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday}
+	weekends = []time.Weekday{time.Saturday, time.Sunday}
+)
+
+// weekdayPrefixes — начала названий дней недели; по ним же распознаются сокращения и падежи
+var weekdayPrefixes = []struct {
+	prefix string
+	day    time.Weekday
+}{
+	{"пн", time.Monday}, {"понед", time.Monday}, {"mon", time.Monday},
+	{"вт", time.Tuesday}, {"вторн", time.Tuesday}, {"tue", time.Tuesday},
+	{"ср", time.Wednesday}, {"сред", time.Wednesday}, {"wed", time.Wednesday},
+	{"чт", time.Thursday}, {"четв", time.Thursday}, {"thu", time.Thursday},
+	{"пт", time.Friday}, {"пятн", time.Friday}, {"fri", time.Friday},
+	{"сб", time.Saturday}, {"субб", time.Saturday}, {"sat", time.Saturday},
+	{"вс", time.Sunday}, {"воскр", time.Sunday}, {"sun", time.Sunday},
+}
+
+// weekdayFillers — служебные слова, которые пропускаются при разборе дней недели
+var weekdayFillers = map[string]bool{
+	"по": true, "on": true, "в": true, "at": true, "every": true, "each": true, "и": true, "and": true,
+	"каждый": true, "каждую": true, "каждое": true, "каждые": true,
+}
+
+func parseWeekday(word string) (time.Weekday, bool) {
+	for _, p := range weekdayPrefixes {
+		// Двухбуквенные сокращения сравниваются целиком, чтобы "вт" не совпадало с началом других слов
+		if word == p.prefix || len([]rune(p.prefix)) > 2 && strings.HasPrefix(word, p.prefix) {
+			return p.day, true
+		}
+	}
+	return 0, false
+}
+
+// parseWeekdays разбирает дни недели: "по будням", "пн-пт", "mon, wed and fri". Пустой список — все дни.
+func parseWeekdays(text string) ([]time.Weekday, error) {
+	words := strings.FieldsFunc(text, func(r rune) bool {
+		return r == ',' || r == ' '
+	})
+
+	var days []time.Weekday
+	all := false
+	for _, word := range words {
+		if weekdayFillers[word] {
+			continue
+		}
+		if group, ok := weekdayGroups[word]; ok {
+			if group == nil {
+				all = true
+			}
+			days = append(days, group...)
+			continue
+		}
+		if from, to, ok := strings.Cut(word, "-"); ok {
+			first, ok1 := parseWeekday(from)
+			last, ok2 := parseWeekday(to)
+			if !ok1 || !ok2 {
+				return nil, fmt.Errorf("%q: %w", word, ErrInvalidSchedule)
+			}
+			for d := first; ; d = (d + 1) % 7 {
+				days = append(days, d)
+				if d == last {
+					break
+				}
+			}
+			continue
+		}
+		day, ok := parseWeekday(word)
+		if !ok {
+			return nil, fmt.Errorf("%q: %w", word, ErrInvalidSchedule)
+		}
+		days = append(days, day)
+	}
+
+	slices.Sort(days)
+	days = slices.Compact(days)
+	if all || len(days) == 7 {
+		return nil, nil
+	}
+	return days, nil
+}
+
+// dailySchedule возвращает расписание уведомлений в указанное время по дням недели days, nil — каждый день
+func dailySchedule(times []TimeOfDay, days []time.Weekday) string {
+	parts := make([]string, 0, len(times))
+	for _, t := range times {
+		parts = append(parts, t.String())
+	}
+	schedule := dailyPrefix + strings.Join(parts, ",")
+	if len(days) > 0 {
+		schedule += daysSep + weekdayField(days)
+	}
+	return schedule
+}
+
+// weekdayField возвращает поле дней недели cron, например "1,2,3,4,5"
+func weekdayField(days []time.Weekday) string {
+	parts := make([]string, 0, len(days))
+	for _, d := range days {
+		parts = append(parts, strconv.Itoa(int(d)))
+	}
+	return strings.Join(parts, ",")
+}
+
+// ScheduleLabel возвращает сохраненное расписание в виде для пользователя на языке lang,
+// например "каждые 45 мин", "пн, ср, пт в 07:30, 19:00"
+func ScheduleLabel(lang i18n.Lang, schedule string) string {
+	switch {
+	case slices.Contains(Intervals, schedule):
+		return i18n.T(lang, "interval."+schedule)
+
+	case strings.HasPrefix(schedule, everyPrefix):
+		d, err := time.ParseDuration(strings.TrimPrefix(schedule, everyPrefix))
+		if err != nil || d <= 0 {
+			return schedule
+		}
+		return i18n.T(lang, "schedule.every", durationLabel(lang, d))
+
+	case strings.HasPrefix(schedule, dailyPrefix):
+		timesText, daysText, _ := strings.Cut(strings.TrimPrefix(schedule, dailyPrefix), daysSep)
+		times := strings.ReplaceAll(timesText, ",", ", ")
+		if daysText == "" {
+			return i18n.T(lang, "schedule.daily", times)
+		}
+		return i18n.T(lang, "schedule.on_days", weekdaysLabel(lang, daysText), times)
+
+	case strings.HasPrefix(schedule, cronPrefix):
+		return i18n.T(lang, "schedule.cron", strings.TrimPrefix(schedule, cronPrefix))
+
+	default:
+		return schedule
+	}
+}
+
+// durationUnitKeys — единицы, из которых складывается интервал в ScheduleLabel, от крупных к мелким
+var durationUnitKeys = []struct {
+	key  string
+	unit time.Duration
+}{
+	{"duration.days", 24 * time.Hour},
+	{"duration.hours", time.Hour},
+	{"duration.minutes", time.Minute},
+	{"duration.seconds", time.Second},
+}
+
+// durationLabel возвращает интервал без нулевых частей на языке lang, например "1 ч 30 мин"
+func durationLabel(lang i18n.Lang, d time.Duration) string {
+	var parts []string
+	for _, u := range durationUnitKeys {
+		if n := d / u.unit; n > 0 {
+			parts = append(parts, i18n.T(lang, u.key, int(n)))
+			d -= n * u.unit
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// weekdaysLabel возвращает дни недели из поля cron вида "1,2,3,4,5" на языке lang, начиная с понедельника
+func weekdaysLabel(lang i18n.Lang, field string) string {
+	var days []time.Weekday
+	for _, part := range strings.Split(field, ",") {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 || n > 6 {
+			return field
+		}
+		days = append(days, time.Weekday(n))
+	}
+	slices.SortFunc(days, func(a, b time.Weekday) int {
+		return (int(a)+6)%7 - (int(b)+6)%7
+	})
+
+	switch {
+	case slices.Equal(days, workdays):
+		return i18n.T(lang, "schedule.weekdays")
+	case slices.Equal(days, weekends):
+		return i18n.T(lang, "schedule.weekends")
+	}
+	names := make([]string, 0, len(days))
+	for _, d := range days {
+		names = append(names, i18n.T(lang, fmt.Sprintf("weekday_short.%d", d)))
+	}
+	return strings.Join(names, ", ")
+}
+
+// zoned сообщает, что расписание привязано к местному времени пользователя
+func zoned(schedule string) bool {
+	return strings.HasPrefix(schedule, dailyPrefix) || strings.HasPrefix(schedule, cronPrefix)
+}
+
+// schedules возвращает расписания cron для сохраненного расписания в часовом поясе timezone.
+// Уведомления по времени суток превращаются в отдельное расписание на каждое время.
+func schedules(schedule, timezone string) ([]cron.Schedule, error) {
+	switch {
+	case slices.Contains(Intervals, schedule):
+		d, err := time.ParseDuration(schedule)
+		if err != nil {
+			return nil, err
+		}
+		return []cron.Schedule{cron.Every(d)}, nil
+
+	case strings.HasPrefix(schedule, everyPrefix):
+		d, err := time.ParseDuration(strings.TrimPrefix(schedule, everyPrefix))
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("%q: %w", schedule, ErrInvalidSchedule)
+		}
+		return []cron.Schedule{cron.Every(d)}, nil
+
+	case strings.HasPrefix(schedule, dailyPrefix):
+		loc, err := LoadTimezone(timezone)
+		if err != nil {
+			return nil, err
+		}
+		timesText, daysText, _ := strings.Cut(strings.TrimPrefix(schedule, dailyPrefix), daysSep)
+		if daysText == "" {
+			daysText = "*"
+		}
+		times, err := parseTimesOfDay(timesText)
+		if err != nil {
+			return nil, err
+		}
+		result := make([]cron.Schedule, 0, len(times))
+		for _, t := range times {
+			spec, err := cron.ParseStandard(fmt.Sprintf("%d %d * * %s", t.Minute, t.Hour, daysText))
+			if err != nil {
+				return nil, fmt.Errorf("%q: %w: %v", schedule, ErrInvalidSchedule, err)
+			}
+			spec.(*cron.SpecSchedule).Location = loc
+			result = append(result, spec)
+		}
+		return result, nil
+
+	case strings.HasPrefix(schedule, cronPrefix):
+		expr := strings.TrimPrefix(schedule, cronPrefix)
+		spec, err := cron.ParseStandard(expr)
+		if err != nil {
+			return nil, fmt.Errorf("%q: %w: %v", schedule, ErrInvalidSchedule, err)
+		}
+		// Часовой пояс, указанный в самом выражении, важнее часового пояса пользователя
+		if s, ok := spec.(*cron.SpecSchedule); ok && !strings.HasPrefix(expr, "CRON_TZ=") && !strings.HasPrefix(expr, "TZ=") {
+			loc, err := LoadTimezone(timezone)
+			if err != nil {
+				return nil, err
+			}
+			s.Location = loc
+		}
+		return []cron.Schedule{spec}, nil
+
+	default:
+		return nil, fmt.Errorf("%q: %w", schedule, ErrInvalidSchedule)
+	}
+}
+
+// nextRuns возвращает n ближайших срабатываний набора расписаний после from
+func nextRuns(scheds []cron.Schedule, from time.Time, n int) []time.Time {
+	next := make([]time.Time, len(scheds))
+	for i, sched := range scheds {
+		next[i] = sched.Next(from)
+	}
+
+	runs := make([]time.Time, 0, n)
+	for len(runs) < n {
+		earliest := -1
+		for i, t := range next {
+			if !t.IsZero() && (earliest < 0 || t.Before(next[earliest])) {
+				earliest = i
+			}
+		}
+		if earliest < 0 {
+			break // Ни одно расписание больше не сработает
+		}
+		run := next[earliest]
+		runs = append(runs, run)
+		for i, t := range next {
+			if t.Equal(run) {
+				next[i] = scheds[i].Next(run)
+			}
+		}
+	}
+	return runs
+}
+
+// checkMinInterval проверяет, что между ближайшими срабатываниями расписания не меньше min
+func checkMinInterval(schedule string, min time.Duration) error {
+	scheds, err := schedules(schedule, "")
+	if err != nil {
+		return err
+	}
+	runs := nextRuns(scheds, time.Now(), checkRuns)
+	if len(runs) == 0 {
+		return fmt.Errorf("%q never runs: %w", schedule, ErrInvalidSchedule)
+	}
+	for i := 1; i < len(runs); i++ {
+		if runs[i].Sub(runs[i-1]) < min {
+			return fmt.Errorf("%q: %w", schedule, ErrScheduleTooFrequent)
+		}
+	}
+	return nil
+}
+
+// timezones — загруженные часовые пояса IANA. Диспетчер разбирает расписания на каждом срабатывании,
+// а time.LoadLocation каждый раз читает базу часовых поясов заново.
+var timezones sync.Map
+
+// LoadTimezone возвращает часовой пояс по названию из базы IANA (Europe/Moscow)
+// или по фиксированному смещению от UTC (+03:00, -04:30). Пустая строка означает UTC.
+func LoadTimezone(name string) (*time.Location, error) {
+	if name == "" {
+		return time.UTC, nil
+	}
+	if fixedOffset(name) {
+		offset, err := time.Parse("-07:00", name)
+		if err != nil {
+			return nil, fmt.Errorf("%q: %w", name, ErrInvalidTimezone)
+		}
+		_, seconds := offset.Zone()
+		return time.FixedZone(name, seconds), nil
+	}
+	if loc, ok := timezones.Load(name); ok {
+		return loc.(*time.Location), nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil || loc == time.Local {
+		return nil, fmt.Errorf("%q: %w", name, ErrInvalidTimezone)
+	}
+	timezones.Store(name, loc)
+	return loc, nil
+}
+
+// fixedOffset сообщает, что часовой пояс задан фиксированным смещением от UTC, а не названием IANA
+func fixedOffset(name string) bool {
+	return name != "" && (name[0] == '+' || name[0] == '-')
+}
+
+// offsetTimezone возвращает часовой пояс с фиксированным смещением от UTC в секундах, например "+05:30"
+func offsetTimezone(seconds int) string {
+	return time.Unix(0, 0).In(time.FixedZone("", seconds)).Format("-07:00")
+}
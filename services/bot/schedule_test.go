@@ -0,0 +1,116 @@
+package bot
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseSchedule(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"интервал из списка по-русски", "1 час", "1h"},
+		{"интервал из списка по-английски", "12 Hours", "12h"},
+		{"код интервала", "15m", "15m"},
+		{"каждые N по-русски", "каждые 2 часа", "every 2h"},
+		{"каждые N минут по-русски", "каждые 45 минут", "every 45m"},
+		{"раз в N дней", "раз в 3 дня", "every 72h"},
+		{"каждый час", "каждый час", "every 1h"},
+		{"every N по-английски", "every 2 hours", "every 2h"},
+		{"every с сокращением", "every 45m", "every 45m"},
+		{"составной интервал", "2h30m", "every 2h30m"},
+		{"интервал cron", "@every 90m", "every 1h30m"},
+		{"по будням по-русски", "по будням в 8:00", "at 08:00 on 1,2,3,4,5"},
+		{"дни недели по-русски", "пн, ср, пт в 7:30 и 19:00", "at 07:30,19:00 on 1,3,5"},
+		{"диапазон дней по-русски", "пн-ср в 9", "at 09:00 on 1,2,3"},
+		{"каждый день по-русски", "в 19:00 и 7:30", "at 07:30,19:00"},
+		{"по выходным", "по выходным в 10.30", "at 10:30 on 0,6"},
+		{"по будням по-английски", "weekdays at 8:00", "at 08:00 on 1,2,3,4,5"},
+		{"дни недели по-английски", "Mon, Wed and Fri at 7:30 and 19:00", "at 07:30,19:00 on 1,3,5"},
+		{"диапазон через воскресенье", "fri-mon at 9:00", "at 09:00 on 0,1,5,6"},
+		{"каждый день по-английски", "daily at 9", "at 09:00"},
+		{"все дни недели", "mon-sun at 6:00", "at 06:00"},
+		{"выражение cron", "0 8 * * 1-5", "cron 0 8 * * 1-5"},
+		{"выражение cron с префиксом", "cron 0 */3 * * *", "cron 0 */3 * * *"},
+		{"сокращение cron", "@daily", "cron @daily"},
+		{"лишние пробелы", "  каждые   2   часа ", "every 2h"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseSchedule(tt.text, DefaultMinInterval)
+			if err != nil {
+				t.Fatalf("parseSchedule(%q): %v", tt.text, err)
+			}
+			if got != tt.want {
+				t.Fatalf("parseSchedule(%q) = %q, ожидается %q", tt.text, got, tt.want)
+			}
+			if _, err := schedules(got, "Europe/Moscow"); err != nil {
+				t.Fatalf("расписание %q не разбирается: %v", got, err)
+			}
+		})
+	}
+}
+
+func TestParseScheduleMinInterval(t *testing.T) {
+	tests := []struct {
+		name        string
+		text        string
+		minInterval time.Duration
+		wantErr     error
+	}{
+		{"интервал из списка", "30 секунд", time.Minute, ErrScheduleTooFrequent},
+		{"каждые N", "every 10s", time.Minute, ErrScheduleTooFrequent},
+		{"выражение cron", "* * * * *", 5 * time.Minute, ErrScheduleTooFrequent},
+		{"время суток", "в 8:00 и 8:01", 5 * time.Minute, ErrScheduleTooFrequent},
+		{"на границе", "every 5m", 5 * time.Minute, nil},
+		{"время суток на границе", "at 8:00 and 8:05", 5 * time.Minute, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseSchedule(tt.text, tt.minInterval)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("parseSchedule(%q, %s): ошибка %v, ожидается %v", tt.text, tt.minInterval, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseScheduleInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		wantErr error
+	}{
+		{"пустая строка", "   ", ErrInvalidSchedule},
+		{"произвольный текст", "привет", ErrInvalidSchedule},
+		{"произвольный текст по-английски", "whenever you like", ErrInvalidSchedule},
+		{"интервал без числа и единицы", "every", ErrInvalidSchedule},
+		{"неизвестная единица", "каждые 2 попугая", ErrInvalidSchedule},
+		{"отрицательный интервал", "every -2h", ErrInvalidSchedule},
+		{"нулевой интервал", "каждые 0 минут", ErrInvalidSchedule},
+		{"дробные секунды", "every 1.5s", ErrInvalidSchedule},
+		{"переполнение числа", "каждые 99999999999999999999 недель", ErrInvalidSchedule},
+		{"переполнение при умножении", "every 36028797018967568 seconds", ErrInvalidSchedule},
+		{"интервал длиннее года", "every 400 days", ErrInvalidSchedule},
+		{"несуществующее время", "в 25:00", ErrInvalidTimeOfDay},
+		{"несуществующие минуты", "at 8:75", ErrInvalidTimeOfDay},
+		{"предлог без времени", "по пятницам в", ErrInvalidSchedule},
+		{"неизвестный день", "по праздникам в 9:00", ErrInvalidSchedule},
+		{"слишком много времени", "at 1,2,3,4,5,6,7", ErrTooManyTimes},
+		{"неверное выражение cron", "cron 0 8 * *", ErrInvalidSchedule},
+		{"неверное поле cron", "0 25 * * *", ErrInvalidSchedule},
+		{"неизвестное сокращение cron", "@sometimes", ErrInvalidSchedule},
+		{"cron без срабатываний", "0 0 30 2 *", ErrInvalidSchedule},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseSchedule(tt.text, DefaultMinInterval)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("parseSchedule(%q) = %q, ошибка %v, ожидается %v", tt.text, got, err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -1,34 +1,152 @@
 package bot
 
 import (
+	"context"
+	"sync/atomic"
+	"time"
+
 	"github.com/ViolettaBykova/viot-tg-sirius/pkg/weather"
 	"github.com/robfig/cron/v3"
-	tb "gopkg.in/tucnak/telebot.v2"
 )
 
 type Option func(s *Service)
 
 type Service struct {
 	store      Storage
-	bot        *tb.Bot
-	cron       *cron.Cron
-	cronJobs   map[int64]int // Карта для хранения задач по ID пользователей
-	weatherAPI *weather.Client
+	bot        Messenger
+	cron       *cron.Cron // Периодические задачи сервиса: опрос предупреждений, сводки после тихих часов
+	weatherAPI weather.Provider
+	geocoder   weather.Geocoder
+	airAPI     weather.AirQualityProvider
+	alertAPI   weather.AlertProvider
+	timezones  weather.TimezoneProvider
+
+	minInterval time.Duration // Минимальный промежуток между уведомлениями по расписанию
+
+	leaderKey      int64         // Ключ advisory-блокировки ведущего экземпляра, 0 — экземпляр всегда ведущий
+	leaderInterval time.Duration // Как часто проверять блокировку ведущего
+	leader         atomic.Bool   // Этот экземпляр сейчас выполняет периодические задачи cron
+	stopElection   context.CancelFunc
+	electionDone   chan struct{}
+
+	dispatchInterval time.Duration // Как часто проверять подписки, которым пора отправить погоду
+	dispatchBatch    int           // Сколько подписок забирать из базы за раз
+	stopDispatch     context.CancelFunc
+	dispatchDone     chan struct{}
+	senderDone       chan struct{}
+	deliveryAttempts int // Сколько раз пытаться отправить уведомление, прежде чем перевести его в dead
+	dispatchWorkers  int // Сколько уведомлений отправлять одновременно, 0 — по одному без группировки по месту
+	fanOut           fanOutCounters
+
+	catchUp CatchUpPolicy // Что делать с уведомлениями, пропущенными за время простоя
+}
+
+// WithAirQuality подключает источник данных о качестве воздуха
+func WithAirQuality(airAPI weather.AirQualityProvider) Option {
+	return func(s *Service) {
+		s.airAPI = airAPI
+	}
+}
+
+// WithMinInterval задает минимальный промежуток между уведомлениями, который можно выбрать в расписании
+func WithMinInterval(d time.Duration) Option {
+	return func(s *Service) {
+		s.minInterval = d
+	}
+}
+
+// WithLeaderElection включает работу нескольких экземпляров с одной базой: периодические задачи cron выполняет
+// только экземпляр, взявший advisory-блокировку key. Раз в interval экземпляры пытаются взять блокировку.
+// Уведомления по подпискам рассылают все экземпляры, разбирая общую очередь в базе.
+func WithLeaderElection(key int64, interval time.Duration) Option {
+	return func(s *Service) {
+		if interval <= 0 {
+			interval = DefaultLeaderInterval
+		}
+		s.leaderKey = key
+		s.leaderInterval = interval
+	}
+}
+
+// WithDispatcher задает, как часто диспетчер проверяет подписки, которым пора отправить погоду,
+// и сколько подписок он забирает из базы за раз
+func WithDispatcher(interval time.Duration, batch int) Option {
+	return func(s *Service) {
+		if interval > 0 {
+			s.dispatchInterval = interval
+		}
+		if batch > 0 {
+			s.dispatchBatch = batch
+		}
+	}
+}
+
+// WithDispatchWorkers задает, сколько уведомлений отправлять одновременно. Уведомления одной пачки
+// группируются по месту, и погода для каждого места запрашивается один раз. При workers <= 0 уведомления
+// отправляются по одному без группировки.
+func WithDispatchWorkers(workers int) Option {
+	return func(s *Service) {
+		s.dispatchWorkers = max(workers, 0)
+	}
+}
+
+// WithDeliveryAttempts задает, сколько раз пытаться отправить уведомление по подписке при ошибках
+func WithDeliveryAttempts(n int) Option {
+	return func(s *Service) {
+		if n > 0 {
+			s.deliveryAttempts = n
+		}
+	}
+}
+
+// WithCatchUp задает, что делать с уведомлениями, пропущенными, пока сервис не работал:
+// ничего не отправлять, отправить одно обычное обновление или обновление со сводкой о пропущенных
+func WithCatchUp(policy CatchUpPolicy) Option {
+	return func(s *Service) {
+		s.catchUp = policy
+	}
+}
+
+// WithAlerts подключает источник официальных предупреждений о неблагоприятной погоде
+func WithAlerts(alertAPI weather.AlertProvider) Option {
+	return func(s *Service) {
+		s.alertAPI = alertAPI
+	}
+}
+
+// WithTimezones подключает определение часового пояса IANA по координатам. Без него часовой пояс
+// определяется по смещению от UTC из ответа сервиса погоды и не учитывает переход на летнее время.
+func WithTimezones(timezones weather.TimezoneProvider) Option {
+	return func(s *Service) {
+		s.timezones = timezones
+	}
 }
 
-func New(store Storage, bot *tb.Bot, cron *cron.Cron, weatherAPI *weather.Client, opts ...Option) *Service {
+func New(store Storage, bot Messenger, scheduler *cron.Cron, weatherAPI weather.Provider, geocoder weather.Geocoder, opts ...Option) *Service {
 	s := &Service{
 		store:      store,
 		bot:        bot,
-		cron:       cron,
-		cronJobs:   make(map[int64]int),
+		cron:       scheduler,
 		weatherAPI: weatherAPI,
+		geocoder:   geocoder,
+
+		minInterval: DefaultMinInterval,
+
+		dispatchInterval: DefaultDispatchInterval,
+		dispatchBatch:    DefaultDispatchBatch,
+		deliveryAttempts: DefaultDeliveryAttempts,
+		dispatchWorkers:  DefaultDispatchWorkers,
+
+		catchUp: DefaultCatchUpPolicy,
 	}
 
 	for _, applyOpt := range opts {
 		applyOpt(s)
 	}
 
-	s.loadScheduledJobs()
+	// Без выбора ведущего экземпляр один и всегда ведущий
+	if s.leaderKey == 0 {
+		s.leader.Store(true)
+	}
 	return s
 }
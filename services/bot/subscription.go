@@ -0,0 +1,407 @@
+package bot
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/ViolettaBykova/viot-tg-sirius/models"
+	"github.com/ViolettaBykova/viot-tg-sirius/pkg/weather"
+)
+
+// ParseSchedule разбирает расписание, введенное пользователем, и возвращает его в виде для хранения
+func (s *Service) ParseSchedule(text string) (string, error) {
+	return parseSchedule(text, s.minInterval)
+}
+
+// MinInterval возвращает минимальный промежуток между уведомлениями по расписанию
+func (s *Service) MinInterval() time.Duration {
+	return s.minInterval
+}
+
+// Subscriptions возвращает подписки пользователя в порядке создания
+func (s *Service) Subscriptions(ctx context.Context, telegramID int64) ([]models.Subscription, error) {
+	ctx, err := s.store.CtxWithTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = s.store.TxRollback(ctx)
+	}()
+	subs, err := s.store.GetUserSubscriptions(ctx, telegramID)
+	if err != nil {
+		log.Printf("Failed to get subscriptions for user %d: %v", telegramID, err)
+		return nil, err
+	}
+	return subs, s.store.TxCommit(ctx)
+}
+
+// GetSubscription возвращает подписку пользователя, ErrSubscriptionNotFound если у него такой нет
+func (s *Service) GetSubscription(ctx context.Context, telegramID, subscriptionID int64) (*models.Subscription, error) {
+	sub, err := s.subscription(ctx, subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+	if sub.TelegramID != telegramID {
+		return nil, fmt.Errorf("subscription %d: %w", subscriptionID, ErrSubscriptionNotFound)
+	}
+	return sub, nil
+}
+
+// subscription возвращает подписку вместе с ее владельцем
+func (s *Service) subscription(ctx context.Context, subscriptionID int64) (*models.Subscription, error) {
+	ctx, err := s.store.CtxWithTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = s.store.TxRollback(ctx)
+	}()
+	sub, err := s.store.GetSubscription(ctx, subscriptionID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("subscription %d: %w", subscriptionID, ErrSubscriptionNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return sub, s.store.TxCommit(ctx)
+}
+
+func (s *Service) enabledSubscriptions(ctx context.Context) ([]models.Subscription, error) {
+	ctx, err := s.store.CtxWithTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = s.store.TxRollback(ctx)
+	}()
+	subs, err := s.store.GetEnabledSubscriptions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return subs, s.store.TxCommit(ctx)
+}
+
+// AddSubscription создает подписку на погоду в месте location по расписанию schedule, полученному от ParseSchedule.
+// Возвращает подписку и часовой пояс, в котором работает расписание.
+func (s *Service) AddSubscription(ctx context.Context, telegramID int64, location weather.Location, schedule string) (*models.Subscription, string, error) {
+	user, err := s.getUser(ctx, telegramID)
+	if err != nil {
+		return nil, "", err
+	}
+	sub := &models.Subscription{
+		TelegramID: telegramID,
+		City:       location.Name,
+		Lat:        &location.Lat,
+		Lon:        &location.Lon,
+		Schedule:   schedule,
+		Format:     models.FormatFull,
+		Enabled:    true,
+		User:       user,
+	}
+	timezone, err := s.addSubscription(ctx, sub)
+	if err != nil {
+		return nil, "", err
+	}
+	return sub, timezone, nil
+}
+
+func (s *Service) addSubscription(ctx context.Context, sub *models.Subscription) (string, error) {
+	if _, err := schedules(sub.Schedule, ""); err != nil {
+		return "", err
+	}
+	timezone, err := s.ensureTimezone(ctx, sub.User, sub.Schedule)
+	if err != nil {
+		return "", err
+	}
+	if sub.NextRunAt, err = firstRun(sub.Schedule, timezone, time.Now()); err != nil {
+		return "", err
+	}
+
+	ctx, err = s.store.CtxWithTx(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		_ = s.store.TxRollback(ctx)
+	}()
+	if err := s.store.CreateSubscription(ctx, sub); err != nil {
+		log.Printf("Failed to create subscription for user %d: %v", sub.TelegramID, err)
+		return "", err
+	}
+	if err := s.store.TxCommit(ctx); err != nil {
+		return "", err
+	}
+
+	log.Printf("Subscription %d for user %d created", sub.ID, sub.TelegramID)
+	return timezone, nil
+}
+
+// SetSchedule устанавливает расписание подписки на место пользователя, указанное в настройках,
+// и создает ее, если такой подписки еще нет. Возвращает ID подписки и часовой пояс, в котором работает расписание.
+func (s *Service) SetSchedule(ctx context.Context, telegramID int64, schedule string) (int64, string, error) {
+	user, err := s.userWithCity(ctx, telegramID)
+	if err != nil {
+		return 0, "", err
+	}
+	subs, err := s.Subscriptions(ctx, telegramID)
+	if err != nil {
+		return 0, "", err
+	}
+
+	home := placeOf(user).Key()
+	for _, sub := range subs {
+		sub.User = user
+		if placeOf(subscriptionUser(&sub)).Key() == home {
+			timezone, err := s.SetSubscriptionSchedule(ctx, telegramID, sub.ID, schedule)
+			return sub.ID, timezone, err
+		}
+	}
+
+	sub := &models.Subscription{
+		TelegramID: telegramID,
+		City:       user.City,
+		Lat:        user.Lat,
+		Lon:        user.Lon,
+		Schedule:   schedule,
+		Format:     models.FormatFull,
+		Enabled:    true,
+		User:       user,
+	}
+	timezone, err := s.addSubscription(ctx, sub)
+	return sub.ID, timezone, err
+}
+
+// SetSubscriptionSchedule меняет расписание подписки и включает ее. Возвращает часовой пояс, в котором работает расписание.
+func (s *Service) SetSubscriptionSchedule(ctx context.Context, telegramID, subscriptionID int64, schedule string) (string, error) {
+	if _, err := schedules(schedule, ""); err != nil {
+		return "", err
+	}
+	sub, err := s.GetSubscription(ctx, telegramID, subscriptionID)
+	if err != nil {
+		return "", err
+	}
+	timezone, err := s.ensureTimezone(ctx, sub.User, schedule)
+	if err != nil {
+		return "", err
+	}
+
+	sub.Schedule = schedule
+	sub.Enabled = true
+	if sub.NextRunAt, err = firstRun(schedule, timezone, time.Now()); err != nil {
+		return "", err
+	}
+	if err := s.updateSubscription(ctx, sub, "schedule", "enabled", "next_run_at"); err != nil {
+		return "", err
+	}
+	return timezone, nil
+}
+
+// SetSubscriptionLocation меняет место подписки
+func (s *Service) SetSubscriptionLocation(ctx context.Context, telegramID, subscriptionID int64, location weather.Location) error {
+	sub := &models.Subscription{
+		ID:         subscriptionID,
+		TelegramID: telegramID,
+		City:       location.Name,
+		Lat:        &location.Lat,
+		Lon:        &location.Lon,
+	}
+	return s.updateSubscription(ctx, sub, "city", "lat", "lon")
+}
+
+// SetSubscriptionFormat меняет вид сообщений подписки
+func (s *Service) SetSubscriptionFormat(ctx context.Context, telegramID, subscriptionID int64, format models.Format) error {
+	if format != models.FormatFull && format != models.FormatShort {
+		return fmt.Errorf("unknown format %q", format)
+	}
+	sub := &models.Subscription{ID: subscriptionID, TelegramID: telegramID, Format: format}
+	return s.updateSubscription(ctx, sub, "format")
+}
+
+// SetSubscriptionEnabled включает или выключает подписку
+func (s *Service) SetSubscriptionEnabled(ctx context.Context, telegramID, subscriptionID int64, enabled bool) error {
+	sub, err := s.GetSubscription(ctx, telegramID, subscriptionID)
+	if err != nil {
+		return err
+	}
+	sub.Enabled = enabled
+	if !enabled {
+		return s.updateSubscription(ctx, sub, "enabled")
+	}
+	// Пока подписка была выключена, время уведомления устарело, поэтому оно рассчитывается заново
+	if sub.NextRunAt, err = firstRun(sub.Schedule, sub.User.Timezone, time.Now()); err != nil {
+		return err
+	}
+	return s.updateSubscription(ctx, sub, "enabled", "next_run_at")
+}
+
+// RemoveSubscription удаляет подписку пользователя
+func (s *Service) RemoveSubscription(ctx context.Context, telegramID, subscriptionID int64) error {
+	ctx, err := s.store.CtxWithTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = s.store.TxRollback(ctx)
+	}()
+	err = s.store.DeleteSubscription(ctx, telegramID, subscriptionID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("subscription %d: %w", subscriptionID, ErrSubscriptionNotFound)
+	}
+	if err != nil {
+		log.Printf("Failed to delete subscription %d: %v", subscriptionID, err)
+		return err
+	}
+	return s.store.TxCommit(ctx)
+}
+
+// NextRuns возвращает n ближайших уведомлений подписки по местному времени пользователя
+func (s *Service) NextRuns(ctx context.Context, telegramID, subscriptionID int64, n int) ([]time.Time, error) {
+	sub, err := s.GetSubscription(ctx, telegramID, subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+	loc, err := LoadTimezone(sub.User.Timezone)
+	if err != nil {
+		return nil, err
+	}
+	scheds, err := schedules(sub.Schedule, sub.User.Timezone)
+	if err != nil {
+		return nil, err
+	}
+	runs := nextRuns(scheds, time.Now(), n)
+	for i := range runs {
+		runs[i] = runs[i].In(loc)
+	}
+	return runs, nil
+}
+
+// updateSubscription сохраняет колонки columns подписки, ErrSubscriptionNotFound если у пользователя такой нет
+func (s *Service) updateSubscription(ctx context.Context, sub *models.Subscription, columns ...string) error {
+	ctx, err := s.store.CtxWithTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = s.store.TxRollback(ctx)
+	}()
+	err = s.store.UpdateSubscription(ctx, sub, columns...)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("subscription %d: %w", sub.ID, ErrSubscriptionNotFound)
+	}
+	if err != nil {
+		log.Printf("Failed to update subscription %d: %v", sub.ID, err)
+		return err
+	}
+	return s.store.TxCommit(ctx)
+}
+
+// rescheduleZoned перепланирует включенные подписки пользователя, привязанные к местному времени
+func (s *Service) rescheduleZoned(ctx context.Context, telegramID int64, timezone string) error {
+	subs, err := s.Subscriptions(ctx, telegramID)
+	if err != nil {
+		return err
+	}
+	for _, sub := range subs {
+		if !sub.Enabled || !zoned(sub.Schedule) {
+			continue
+		}
+		if err := s.scheduleSubscription(ctx, &sub, timezone); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rescheduleEnabled заново рассчитывает время уведомлений по включенным подпискам пользователя,
+// когда оно устарело, пока уведомления ему не отправлялись
+func (s *Service) rescheduleEnabled(ctx context.Context, user *models.User) error {
+	subs, err := s.Subscriptions(ctx, user.TelegramID)
+	if err != nil {
+		return err
+	}
+	for _, sub := range subs {
+		if !sub.Enabled {
+			continue
+		}
+		if err := s.scheduleSubscription(ctx, &sub, user.Timezone); err != nil {
+			log.Printf("Ошибка планирования подписки %d пользователя %d: %v", sub.ID, user.TelegramID, err)
+		}
+	}
+	return nil
+}
+
+// ensureTimezone возвращает часовой пояс пользователя. Если он не задан или определен по месту как
+// фиксированное смещение, определяет его по городу пользователя заново. Место подписки для этого
+// не используется: часовой пояс один на все подписки пользователя.
+// Интервалам часовой пояс не нужен, поэтому для них ошибка определения не мешает сохранить расписание.
+func (s *Service) ensureTimezone(ctx context.Context, user *models.User, schedule string) (string, error) {
+	if user.Timezone != "" && !(user.TimezoneAuto && fixedOffset(user.Timezone) && s.timezones != nil) {
+		return user.Timezone, nil
+	}
+	if user.City == "" && (user.Lat == nil || user.Lon == nil) {
+		if user.Timezone == "" && zoned(schedule) {
+			return "", fmt.Errorf("user %d: %w", user.TelegramID, ErrCityNotSet)
+		}
+		return user.Timezone, nil
+	}
+	timezone, err := s.redetectTimezone(ctx, user)
+	if err != nil && zoned(schedule) {
+		return "", err
+	}
+	return timezone, nil
+}
+
+// redetectTimezone определяет часовой пояс по месту user, сохраняет его и переносит уведомления
+// по времени суток на новое местное время, если пояс изменился
+func (s *Service) redetectTimezone(ctx context.Context, user *models.User) (string, error) {
+	timezone, err := s.detectTimezone(ctx, user)
+	if err != nil {
+		return user.Timezone, err
+	}
+	if err := s.setTimezone(ctx, user.TelegramID, timezone, true); err != nil {
+		return user.Timezone, err
+	}
+	if user.Timezone == "" || user.Timezone == timezone {
+		return timezone, nil
+	}
+	return timezone, s.rescheduleZoned(ctx, user.TelegramID, timezone)
+}
+
+// detectTimezone определяет часовой пояс по координатам места user. Если это не удалось,
+// возвращает смещение от UTC из ответа сервиса погоды.
+func (s *Service) detectTimezone(ctx context.Context, user *models.User) (string, error) {
+	if s.timezones != nil {
+		timezone, err := s.lookupTimezone(ctx, user)
+		if err == nil {
+			return timezone, nil
+		}
+		log.Printf("Ошибка определения часового пояса пользователя %d, используется смещение от UTC: %v", user.TelegramID, err)
+	}
+	conditions, err := s.currentWeather(ctx, user)
+	if err != nil {
+		return "", err
+	}
+	return offsetTimezone(conditions.UTCOffset), nil
+}
+
+func (s *Service) lookupTimezone(ctx context.Context, user *models.User) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, weatherRequestTimeout)
+	defer cancel()
+
+	coords, err := s.coordsOf(ctx, user)
+	if err != nil {
+		return "", err
+	}
+	timezone, err := s.timezones.Timezone(ctx, coords)
+	if err != nil {
+		return "", err
+	}
+	if _, err := LoadTimezone(timezone); err != nil {
+		return "", err
+	}
+	return timezone, nil
+}
@@ -2,15 +2,19 @@ package bot
 
 import (
 	"context"
+	"fmt"
 	"log"
 
 	"github.com/ViolettaBykova/viot-tg-sirius/models"
 	"github.com/ViolettaBykova/viot-tg-sirius/models/scenes"
+	"github.com/ViolettaBykova/viot-tg-sirius/models/units"
+	"github.com/ViolettaBykova/viot-tg-sirius/pkg/i18n"
+	"github.com/ViolettaBykova/viot-tg-sirius/pkg/weather"
 	"github.com/google/uuid"
 )
 
-// CreateUser создает нового пользователя или игнорирует, если он уже существует
-func (s *Service) CreateUser(ctx context.Context, telegramID int64, city string) error {
+// CreateUser создает нового пользователя с языком lang или игнорирует, если он уже существует
+func (s *Service) CreateUser(ctx context.Context, telegramID int64, city string, lang i18n.Lang) error {
 	ctx, err := s.store.CtxWithTx(ctx)
 	if err != nil {
 		return err
@@ -23,6 +27,7 @@ func (s *Service) CreateUser(ctx context.Context, telegramID int64, city string)
 		ID:         id,
 		TelegramID: telegramID,
 		City:       city,
+		Language:   lang,
 	}
 
 	err = s.store.CreateUser(ctx, user)
@@ -68,6 +73,25 @@ func (s *Service) GetUserScene(ctx context.Context, telegramID int64) (scenes.Sc
 	return user.Scene, s.store.TxCommit(ctx)
 }
 
+// userWithCity возвращает пользователя, у которого указан город, иначе ErrCityNotSet
+func (s *Service) userWithCity(ctx context.Context, telegramID int64) (*models.User, error) {
+	ctx, err := s.store.CtxWithTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = s.store.TxRollback(ctx)
+	}()
+	user, err := s.store.GetUser(ctx, telegramID)
+	if err != nil {
+		return nil, err
+	}
+	if user.City == "" {
+		return nil, fmt.Errorf("user %d: %w", telegramID, ErrCityNotSet)
+	}
+	return user, s.store.TxCommit(ctx)
+}
+
 // SetUserScene устанавливает новую сцену для пользователя
 func (s *Service) SetUserScene(ctx context.Context, telegramID int64, scene scenes.Scene) error {
 	ctx, err := s.store.CtxWithTx(ctx)
@@ -85,6 +109,109 @@ func (s *Service) SetUserScene(ctx context.Context, telegramID int64, scene scen
 }
 
 func (s *Service) SetCity(ctx context.Context, telegramID int64, city string) error {
+	txCtx, err := s.store.CtxWithTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = s.store.TxRollback(txCtx)
+	}()
+	user, err := s.store.GetUser(txCtx, telegramID)
+	if err != nil {
+		return err
+	}
+	err = s.store.SetCity(txCtx, telegramID, city)
+	if err != nil {
+		log.Printf("Failed to set city for user %d: %v", telegramID, err)
+		return err
+	}
+	if err := s.store.TxCommit(txCtx); err != nil {
+		return err
+	}
+
+	user.City, user.Lat, user.Lon = city, nil, nil
+	s.movedTimezone(ctx, user)
+	return nil
+}
+
+// SetLocation сохраняет выбранный пользователем населённый пункт с координатами.
+// Подписка на прежний город пользователя переезжает вместе с ним.
+func (s *Service) SetLocation(ctx context.Context, telegramID int64, location weather.Location) error {
+	txCtx, err := s.store.CtxWithTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = s.store.TxRollback(txCtx)
+	}()
+	user, err := s.store.GetUser(txCtx, telegramID)
+	if err != nil {
+		return err
+	}
+	subs, err := s.store.GetUserSubscriptions(txCtx, telegramID)
+	if err != nil {
+		return err
+	}
+	err = s.store.SetLocation(txCtx, telegramID, location.Name, location.Lat, location.Lon)
+	if err != nil {
+		log.Printf("Failed to set location for user %d: %v", telegramID, err)
+		return err
+	}
+
+	home := placeOf(user).Key()
+	for _, sub := range subs {
+		sub.User = user
+		if placeOf(subscriptionUser(&sub)).Key() != home {
+			continue
+		}
+		sub.City, sub.Lat, sub.Lon = location.Name, &location.Lat, &location.Lon
+		if err := s.store.UpdateSubscription(txCtx, &sub, "city", "lat", "lon"); err != nil {
+			log.Printf("Failed to move subscription %d for user %d: %v", sub.ID, telegramID, err)
+			return err
+		}
+		break
+	}
+	if err := s.store.TxCommit(txCtx); err != nil {
+		return err
+	}
+
+	user.City, user.Lat, user.Lon = location.Name, &location.Lat, &location.Lon
+	s.movedTimezone(ctx, user)
+	return nil
+}
+
+// movedTimezone определяет часовой пояс заново после смены места пользователя, если он не задан вручную.
+// Ошибка определения не мешает смене места: уведомления остаются в прежнем часовом поясе.
+func (s *Service) movedTimezone(ctx context.Context, user *models.User) {
+	if !user.TimezoneAuto {
+		return
+	}
+	if _, err := s.redetectTimezone(ctx, user); err != nil {
+		log.Printf("Ошибка определения часового пояса пользователя %d после смены места: %v", user.TelegramID, err)
+	}
+}
+
+// GetUserUnits возвращает единицы измерения, выбранные пользователем
+func (s *Service) GetUserUnits(ctx context.Context, telegramID int64) (units.Units, error) {
+	ctx, err := s.store.CtxWithTx(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		_ = s.store.TxRollback(ctx)
+	}()
+	user, err := s.store.GetUser(ctx, telegramID)
+	if err != nil {
+		return "", err
+	}
+	return user.Units, s.store.TxCommit(ctx)
+}
+
+// SetUnits устанавливает единицы измерения для пользователя
+func (s *Service) SetUnits(ctx context.Context, telegramID int64, u units.Units) error {
+	if !u.Valid() {
+		return fmt.Errorf("unknown units %q", u)
+	}
 	ctx, err := s.store.CtxWithTx(ctx)
 	if err != nil {
 		return err
@@ -92,16 +219,88 @@ func (s *Service) SetCity(ctx context.Context, telegramID int64, city string) er
 	defer func() {
 		_ = s.store.TxRollback(ctx)
 	}()
-	err = s.store.SetCity(ctx, telegramID, city)
+	err = s.store.SetUnits(ctx, telegramID, u)
 	if err != nil {
-		log.Printf("Failed to set city for user %d: %v", telegramID, err)
+		log.Printf("Failed to set units for user %d: %v", telegramID, err)
 		return err
 	}
 	return s.store.TxCommit(ctx)
 }
 
-// SetUpdateInterval устанавливает интервал обновлений для пользователя
-func (s *Service) SetUpdateInterval(ctx context.Context, telegramID int64, interval string) error {
+// GetUserLanguage возвращает язык сообщений, выбранный пользователем
+func (s *Service) GetUserLanguage(ctx context.Context, telegramID int64) (i18n.Lang, error) {
+	ctx, err := s.store.CtxWithTx(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		_ = s.store.TxRollback(ctx)
+	}()
+	user, err := s.store.GetUser(ctx, telegramID)
+	if err != nil {
+		return "", err
+	}
+	return user.Language, s.store.TxCommit(ctx)
+}
+
+// SetLanguage устанавливает язык сообщений для пользователя
+func (s *Service) SetLanguage(ctx context.Context, telegramID int64, lang i18n.Lang) error {
+	if !lang.Valid() {
+		return fmt.Errorf("unknown language %q", lang)
+	}
+	ctx, err := s.store.CtxWithTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = s.store.TxRollback(ctx)
+	}()
+	err = s.store.SetLanguage(ctx, telegramID, lang)
+	if err != nil {
+		log.Printf("Failed to set language for user %d: %v", telegramID, err)
+		return err
+	}
+	return s.store.TxCommit(ctx)
+}
+
+// getUser возвращает пользователя в отдельной транзакции
+func (s *Service) getUser(ctx context.Context, telegramID int64) (*models.User, error) {
+	ctx, err := s.store.CtxWithTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = s.store.TxRollback(ctx)
+	}()
+	user, err := s.store.GetUser(ctx, telegramID)
+	if err != nil {
+		return nil, err
+	}
+	return user, s.store.TxCommit(ctx)
+}
+
+// GetUserTimezone возвращает часовой пояс пользователя, пустая строка — не задан
+func (s *Service) GetUserTimezone(ctx context.Context, telegramID int64) (string, error) {
+	user, err := s.getUser(ctx, telegramID)
+	if err != nil {
+		return "", err
+	}
+	return user.Timezone, nil
+}
+
+// SetTimezone устанавливает часовой пояс пользователя и переносит уведомления по времени суток на новое местное время
+func (s *Service) SetTimezone(ctx context.Context, telegramID int64, timezone string) error {
+	if _, err := LoadTimezone(timezone); err != nil {
+		return err
+	}
+	if err := s.setTimezone(ctx, telegramID, timezone, false); err != nil {
+		return err
+	}
+
+	return s.rescheduleZoned(ctx, telegramID, timezone)
+}
+
+func (s *Service) setTimezone(ctx context.Context, telegramID int64, timezone string, auto bool) error {
 	ctx, err := s.store.CtxWithTx(ctx)
 	if err != nil {
 		return err
@@ -109,9 +308,9 @@ func (s *Service) SetUpdateInterval(ctx context.Context, telegramID int64, inter
 	defer func() {
 		_ = s.store.TxRollback(ctx)
 	}()
-	err = s.store.SetUpdateInterval(ctx, telegramID, interval)
+	err = s.store.SetTimezone(ctx, telegramID, timezone, auto)
 	if err != nil {
-		log.Printf("Failed to set update interval for user %d: %v", telegramID, err)
+		log.Printf("Failed to set timezone for user %d: %v", telegramID, err)
 		return err
 	}
 	return s.store.TxCommit(ctx)
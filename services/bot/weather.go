@@ -2,140 +2,301 @@ package bot
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"time"
 
-	"github.com/robfig/cron/v3"
+	"github.com/ViolettaBykova/viot-tg-sirius/models"
+	"github.com/ViolettaBykova/viot-tg-sirius/pkg/i18n"
+	"github.com/ViolettaBykova/viot-tg-sirius/pkg/weather"
 	tb "gopkg.in/tucnak/telebot.v2"
 )
 
-// StartScheduler запускает cron планировщик
+// weatherRequestTimeout ограничивает общее время запроса погоды вместе с повторами,
+// чтобы зависший провайдер не блокировал рассылку
+const weatherRequestTimeout = time.Minute
+
+// Intervals — коды доступных интервалов обновлений. Названия для пользователя лежат
+// в каталоге сообщений под ключами "interval.<код>".
+var Intervals = []string{"30s", "1m", "15m", "1h", "6h", "12h"}
+
+// StartScheduler заполняет время следующего уведомления у подписок, где его нет, и запускает диспетчер уведомлений,
+// отправку уведомлений из очереди и cron с периодическими задачами. При выборе ведущего cron запускается, только когда экземпляр станет ведущим.
 func (s *Service) StartScheduler() {
-	s.cron.Start()
+	s.scheduleUnscheduled(context.Background())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.stopDispatch = cancel
+	s.dispatchDone = make(chan struct{})
+	s.senderDone = make(chan struct{})
+	go s.runDispatcher(ctx)
+	go s.runSender(ctx)
+
+	if s.leaderKey == 0 {
+		s.cron.Start()
+		return
+	}
+
+	ctx, cancel = context.WithCancel(context.Background())
+	s.stopElection = cancel
+	s.electionDone = make(chan struct{})
+	go s.runElection(ctx)
 }
 
-// StopScheduler останавливает cron планировщик
+// StopScheduler останавливает диспетчер и cron и отдает роль ведущего другим экземплярам
 func (s *Service) StopScheduler() {
-	s.cron.Stop()
+	if s.stopDispatch != nil {
+		s.stopDispatch()
+		<-s.dispatchDone
+		<-s.senderDone
+	}
+	if s.stopElection != nil {
+		s.stopElection()
+		<-s.electionDone
+		return
+	}
+	<-s.cron.Stop().Done()
 }
 
-func (s *Service) loadScheduledJobs() {
-	ctx := context.Background()
-
-	ctx, err := s.store.CtxWithTx(ctx)
+// scheduleUnscheduled рассчитывает время следующего уведомления для включенных подписок, у которых его нет,
+// например перенесенных миграцией из старой схемы
+func (s *Service) scheduleUnscheduled(ctx context.Context) {
+	subs, err := s.unscheduledSubscriptions(ctx)
 	if err != nil {
+		log.Printf("Ошибка при загрузке незапланированных подписок: %v", err)
 		return
 	}
-	defer func() {
-		if err != nil {
-			_ = s.store.TxRollback(ctx)
+	for i := range subs {
+		if err := s.scheduleSubscription(ctx, &subs[i], subs[i].User.Timezone); err != nil {
+			log.Printf("Ошибка планирования подписки %d пользователя %d: %v", subs[i].ID, subs[i].TelegramID, err)
 		}
+	}
+}
+
+// scheduleSubscription сохраняет время ближайшего уведомления подписки по расписанию в часовом поясе timezone
+func (s *Service) scheduleSubscription(ctx context.Context, sub *models.Subscription, timezone string) error {
+	next, err := firstRun(sub.Schedule, timezone, time.Now())
+	if err != nil {
+		return err
+	}
+	sub.NextRunAt = next
+	return s.updateSubscription(ctx, sub, "next_run_at")
+}
+
+// firstRun возвращает первое срабатывание расписания после from, nil — расписание больше не сработает
+func firstRun(schedule, timezone string, from time.Time) (*time.Time, error) {
+	scheds, err := schedules(schedule, timezone)
+	if err != nil {
+		return nil, err
+	}
+	runs := nextRuns(scheds, from, 1)
+	if len(runs) == 0 {
+		return nil, nil
+	}
+	return &runs[0], nil
+}
 
+func (s *Service) unscheduledSubscriptions(ctx context.Context) ([]models.Subscription, error) {
+	ctx, err := s.store.CtxWithTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = s.store.TxRollback(ctx)
 	}()
-	users, err := s.store.GetAllUsersWithInterval(ctx)
+	subs, err := s.store.GetUnscheduledSubscriptions(ctx)
 	if err != nil {
-		log.Printf("Ошибка при загрузке пользователей для cron задач: %v", err)
-		return
+		return nil, err
 	}
-	if err := s.store.TxCommit(ctx); err != nil {
+	return subs, s.store.TxCommit(ctx)
+}
+
+// handleUpdateError сообщает пользователю о причине ошибки. Если город подписки не найден,
+// выключает подписку, чтобы пользователь исправил место в /subscriptions.
+func (s *Service) handleUpdateError(ctx context.Context, sub *models.Subscription, err error) {
+	lang := sub.User.Language
+	if NeedsCity(err) {
+		if err := s.SetSubscriptionEnabled(ctx, sub.TelegramID, sub.ID, false); err != nil {
+			log.Printf("Ошибка отключения подписки %d: %v", sub.ID, err)
+		}
+		s.bot.Send(&tb.User{ID: sub.TelegramID}, i18n.T(lang, "subscription.city_not_found", sub.City))
 		return
 	}
+	s.bot.Send(&tb.User{ID: sub.TelegramID}, UserMessage(lang, err))
+}
 
-	for _, user := range users {
-		if user.UpdateInterval != "" {
-			// Планируем задачу cron для каждого пользователя
-			if err := s.ScheduleWeatherUpdate(ctx, user.TelegramID, user.UpdateInterval); err != nil {
-				log.Printf("Ошибка планирования обновлений для пользователя %d: %v", user.TelegramID, err)
-			}
-		}
+// errUpdateSkipped — обновление по подписке сознательно не отправлено, повторять попытку не нужно
+var errUpdateSkipped = errors.New("update skipped")
+
+// sendSubscriptionUpdate отправляет сообщение с погодой по подписке и возвращает отправленное сообщение.
+// Если отправлять сейчас не нужно, возвращает ошибку errUpdateSkipped с причиной.
+func (s *Service) sendSubscriptionUpdate(ctx context.Context, sub *models.Subscription, header string) (*tb.Message, error) {
+	options, err := s.updateOptions(ctx, sub, time.Now())
+	if err != nil {
+		return nil, err
 	}
 
+	// Получаем данные о погоде с помощью weatherAPI
+	weatherData, err := s.currentWeather(ctx, subscriptionUser(sub))
+	if err != nil {
+		log.Printf("Ошибка при получении данных о погоде: %v", err)
+		return nil, err
+	}
+	return s.sendConditions(ctx, sub, header, weatherData, options)
 }
 
-// ScheduleWeatherUpdate создает или обновляет задачу для пользователя
-func (s *Service) ScheduleWeatherUpdate(ctx context.Context, telegramID int64, interval string) error {
-	// Удаляем существующую задачу для пользователя, если она есть
-	if entryID, exists := s.cronJobs[telegramID]; exists {
-		s.cron.Remove(cron.EntryID(entryID))
+// updateOptions проверяет, нужно ли сейчас отправлять обновление по подписке, и возвращает параметры отправки.
+// Если не нужно, возвращает ошибку errUpdateSkipped с причиной.
+func (s *Service) updateOptions(ctx context.Context, sub *models.Subscription, now time.Time) ([]interface{}, error) {
+	if !sub.Enabled {
+		return nil, fmt.Errorf("%w: subscription is disabled", errUpdateSkipped)
+	}
+	if !sub.User.Active() {
+		return nil, fmt.Errorf("%w: user is inactive", errUpdateSkipped)
+	}
+	if sub.User.Stopped || sub.User.IsPaused(now) {
+		// Диспетчер не выбирает такие подписки, но пауза могла начаться, пока уведомление ждало отправки
+		return nil, fmt.Errorf("%w: notifications are paused", errUpdateSkipped)
 	}
 
-	// Получаем спецификацию cron для интервала
-	cronSpec, err := getCronSpec(interval)
-	if err != nil {
-		return err
+	// В тихие часы обновление либо уходит без звука, либо пропускается до сводки после них
+	var options []interface{}
+	if inQuietHours(sub.User, now) {
+		if sub.User.QuietMode != models.QuietSilent {
+			if !sub.User.QuietMissed {
+				if err := s.setQuietMissed(ctx, sub.TelegramID, true); err != nil {
+					return nil, err
+				}
+			}
+			return nil, fmt.Errorf("%w: quiet hours", errUpdateSkipped)
+		}
+		options = append(options, tb.Silent)
 	}
+	return options, nil
+}
 
-	// Создаем новую задачу для обновления погоды
-	entryID, err := s.cron.AddFunc(cronSpec, func() {
-		err := s.sendWeatherUpdate(ctx, telegramID)
-		if err != nil {
-			log.Printf("Error sending weather update for user %d: %v", telegramID, err)
-			s.bot.Send(&tb.User{ID: telegramID}, "Ошибка получение данных")
+// sendConditions формирует сообщение с погодой weatherData по подписке и отправляет его пользователю.
+// В режиме уведомлений только при изменениях возвращает errUpdateSkipped, если погода изменилась незаметно.
+func (s *Service) sendConditions(ctx context.Context, sub *models.Subscription, header string,
+	weatherData *weather.Conditions, options []interface{}) (*tb.Message, error) {
+	if !significantChange(sub, weatherData) {
+		return nil, fmt.Errorf("%w: no significant change", errUpdateSkipped)
+	}
+	user := subscriptionUser(sub)
+	var message string
+	if sub.Format == models.FormatShort {
+		message = formatShort(weatherData, user.Units, user.Language)
+	} else {
+		message = formatConditions(weatherData, user.Units, user.Language)
+		if line := s.checkAirQuality(ctx, sub); line != "" {
+			message += "\n" + line
 		}
-	})
+	}
+	if header != "" {
+		message = header + "\n\n" + message
+	}
+	msg, err := s.bot.Send(&tb.User{ID: sub.TelegramID}, message, options...)
 	if err != nil {
-		return err
+		return nil, err
 	}
-
-	// Сохраняем ID задачи
-	s.cronJobs[telegramID] = int(entryID)
-	return nil
+	// Снимок сохраняется вместе с результатом отправки, с ним сравнивается следующая погода
+	sub.LastSent = snapshotOf(weatherData, time.Now())
+	return msg, nil
 }
 
-// sendWeatherUpdate отправляет сообщение с прогнозом погоды пользователю
-func (s *Service) sendWeatherUpdate(ctx context.Context, telegramID int64) error {
-	ctx = context.WithValue(ctx, "tx", nil)
-	ctx, err := s.store.CtxWithTx(ctx)
+// GetForecast возвращает прогноз погоды по дням для города пользователя
+func (s *Service) GetForecast(ctx context.Context, telegramID int64) (*weather.Forecast, error) {
+	user, err := s.userWithCity(ctx, telegramID)
 	if err != nil {
-		return err
-	}
-	defer func() {
-		_ = s.store.TxRollback(ctx)
-	}()
-	city, err := s.store.GetUserCity(ctx, telegramID)
-	if err != nil || city == "" {
-		return fmt.Errorf("city not found for user %d: %v", telegramID, err)
+		return nil, err
 	}
-	if err := s.store.TxCommit(ctx); err != nil {
-		return err
+
+	forecast, err := s.forecast(ctx, user)
+	if err != nil {
+		log.Printf("Ошибка при получении прогноза погоды: %v", err)
+		return nil, err
 	}
-	// Получаем данные о погоде с помощью weatherAPI
-	weatherData, err := s.weatherAPI.GetCurrentWeather(city)
+	return forecast, nil
+}
+
+// FindLocations ищет населённые пункты, подходящие под введённое название, с названиями на языке lang
+func (s *Service) FindLocations(ctx context.Context, query string, lang i18n.Lang) ([]weather.Location, error) {
+	locations, err := s.geocoder.FindLocations(ctx, query, string(lang))
 	if err != nil {
-		log.Printf("Ошибка при получении данных о погоде: %v", err)
-		return err
+		log.Printf("Ошибка геокодирования %q: %v", query, err)
+		return nil, err
 	}
+	return locations, nil
+}
 
-	// Формируем сообщение и отправляем его пользователю
-	message := fmt.Sprintf("Погода в %s: %s\nТемпература: %.1f°C\nВлажность: %d%%", weatherData.Name, weatherData.Weather[0].Description, weatherData.Main.Temp, weatherData.Main.Humidity)
-	_, err = s.bot.Send(&tb.User{ID: telegramID}, message)
+// LocationByCoords определяет название места по координатам.
+// Если геокодер недоступен или ничего не нашел, названием служат сами координаты.
+func (s *Service) LocationByCoords(ctx context.Context, lat, lon float64, lang i18n.Lang) weather.Location {
+	location, err := s.geocoder.ReverseGeocode(ctx, lat, lon, string(lang))
+	if err != nil {
+		log.Printf("Ошибка обратного геокодирования %.4f, %.4f: %v", lat, lon, err)
+	}
+	if location == nil {
+		return weather.Location{
+			Name: fmt.Sprintf("%.4f, %.4f", lat, lon),
+			Lat:  lat,
+			Lon:  lon,
+		}
+	}
+	return *location
+}
 
-	return err
+// currentWeather запрашивает текущую погоду в месте пользователя
+func (s *Service) currentWeather(ctx context.Context, user *models.User) (*weather.Conditions, error) {
+	ctx, cancel := context.WithTimeout(ctx, weatherRequestTimeout)
+	defer cancel()
+	conditions, err := s.weatherAPI.Current(ctx, placeOf(user))
+	if err != nil {
+		return nil, err
+	}
+	return withUserCity(conditions, user), nil
+}
 
+// withUserCity возвращает копию погоды с названием места, которое выбрал пользователь.
+// По координатам провайдер возвращает ближайшую станцию, поэтому показывается название пользователя.
+func withUserCity(conditions *weather.Conditions, user *models.User) *weather.Conditions {
+	c := *conditions
+	if user.Lat != nil || c.City == "" {
+		c.City = user.City
+	}
+	return &c
 }
 
-// getCronSpec возвращает выражение cron для заданного интервала
-func getCronSpec(interval string) (string, error) {
-	switch interval {
-	case "30 секунд":
-		return "@every 30s", nil
-	case "1 минута":
-		return "@every 1m", nil
-	case "15 минут":
-		return "@every 15m", nil
-	case "1 час":
-		return "@every 1h", nil
-	case "6 часов":
-		return "@every 6h", nil
-	case "12 часов":
-		return "@every 12h", nil
-	default:
-		return "@every 1h", nil
+// forecast запрашивает прогноз в месте пользователя
+func (s *Service) forecast(ctx context.Context, user *models.User) (*weather.Forecast, error) {
+	ctx, cancel := context.WithTimeout(ctx, weatherRequestTimeout)
+	defer cancel()
+	forecast, err := s.weatherAPI.Forecast(ctx, placeOf(user))
+	if err != nil {
+		return nil, err
+	}
+	if user.Lat != nil || forecast.City == "" {
+		forecast.City = user.City
 	}
+	return forecast, nil
 }
 
-// fetchWeatherData – функция-заглушка для получения данных о погоде
-func fetchWeatherData(city string) string {
-	// Здесь будет вызов API для получения данных о погоде
-	return "ясно, +25°C"
+// subscriptionUser возвращает копию владельца подписки, у которой место заменено местом подписки,
+// чтобы запрашивать погоду и качество воздуха так же, как для места пользователя
+func subscriptionUser(sub *models.Subscription) *models.User {
+	user := *sub.User
+	user.City = sub.City
+	user.Lat = sub.Lat
+	user.Lon = sub.Lon
+	return &user
+}
+
+// placeOf возвращает место пользователя: координаты, если они известны, иначе название города
+func placeOf(user *models.User) weather.Place {
+	place := weather.Place{City: user.City, Lang: string(user.Language)}
+	if user.Lat != nil && user.Lon != nil {
+		place.Coords = &weather.Coords{Lat: *user.Lat, Lon: *user.Lon}
+	}
+	return place
 }
@@ -0,0 +1,58 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/ViolettaBykova/viot-tg-sirius/models"
+)
+
+// ClaimAlert отмечает предупреждение как отправленное пользователю.
+// Возвращает false, если оно уже было отправлено ранее.
+func (s *Storage) ClaimAlert(ctx context.Context, alertID string, telegramID int64, expiresAt time.Time) (bool, error) {
+	tx, ok := txFromCtx(ctx)
+	if !ok {
+		return false, ErrTxNotFound
+	}
+
+	res, err := tx.NewInsert().
+		Model(&models.SentAlert{AlertID: alertID, TelegramID: telegramID, ExpiresAt: expiresAt}).
+		On("CONFLICT (alert_id, telegram_id) DO NOTHING").
+		Exec(ctx)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n == 1, nil
+}
+
+// ReleaseAlert снимает отметку об отправке, чтобы предупреждение отправилось при следующем опросе
+func (s *Storage) ReleaseAlert(ctx context.Context, alertID string, telegramID int64) error {
+	tx, ok := txFromCtx(ctx)
+	if !ok {
+		return ErrTxNotFound
+	}
+
+	_, err := tx.NewDelete().
+		Model((*models.SentAlert)(nil)).
+		Where("alert_id = ? AND telegram_id = ?", alertID, telegramID).
+		Exec(ctx)
+	return err
+}
+
+// DeleteExpiredAlerts удаляет отметки о предупреждениях, истекших до before
+func (s *Storage) DeleteExpiredAlerts(ctx context.Context, before time.Time) error {
+	tx, ok := txFromCtx(ctx)
+	if !ok {
+		return ErrTxNotFound
+	}
+
+	_, err := tx.NewDelete().
+		Model((*models.SentAlert)(nil)).
+		Where("expires_at < ?", before).
+		Exec(ctx)
+	return err
+}
@@ -0,0 +1,165 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/ViolettaBykova/viot-tg-sirius/models"
+	"github.com/uptrace/bun"
+)
+
+// CreateDeliveries ставит уведомления в очередь на отправку. Уведомление за срабатывание,
+// которое уже есть в очереди, повторно не добавляется.
+func (s *Storage) CreateDeliveries(ctx context.Context, deliveries []models.Delivery) error {
+	tx, ok := txFromCtx(ctx)
+	if !ok {
+		return ErrTxNotFound
+	}
+	if len(deliveries) == 0 {
+		return nil
+	}
+
+	_, err := tx.NewInsert().
+		Model(&deliveries).
+		On("CONFLICT (subscription_id, scheduled_at) DO NOTHING").
+		Returning("NULL").
+		Exec(ctx)
+	return err
+}
+
+// ClaimDeliveries блокирует до limit ожидающих уведомлений, время попытки которых наступило к now,
+// вместе с подписками и их владельцами. Строки, заблокированные другими транзакциями, пропускаются.
+func (s *Storage) ClaimDeliveries(ctx context.Context, now time.Time, limit int) ([]models.Delivery, error) {
+	tx, ok := txFromCtx(ctx)
+	if !ok {
+		return nil, ErrTxNotFound
+	}
+
+	var deliveries []models.Delivery
+	err := tx.NewSelect().
+		Model(&deliveries).
+		Relation("Subscription").
+		Relation("Subscription.User").
+		Where("delivery.status = ?", models.DeliveryPending).
+		Where("delivery.next_attempt_at <= ?", now).
+		OrderExpr("delivery.next_attempt_at").
+		Limit(limit).
+		For("UPDATE OF delivery SKIP LOCKED").
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+// UpdateDeliveries сохраняет колонки columns нескольких уведомлений одним запросом
+func (s *Storage) UpdateDeliveries(ctx context.Context, deliveries []models.Delivery, columns ...string) error {
+	tx, ok := txFromCtx(ctx)
+	if !ok {
+		return ErrTxNotFound
+	}
+	if len(deliveries) == 0 {
+		return nil
+	}
+
+	_, err := tx.NewUpdate().
+		Model(&deliveries).
+		Column(columns...).
+		Bulk().
+		Exec(ctx)
+	return err
+}
+
+// UpdateDelivery сохраняет результат отправки уведомления в колонках columns, если уведомление еще отправляется.
+// Возвращает false, если уведомление уже не в статусе sending: например, его посчитал прерванным другой экземпляр.
+func (s *Storage) UpdateDelivery(ctx context.Context, d *models.Delivery, columns ...string) (bool, error) {
+	tx, ok := txFromCtx(ctx)
+	if !ok {
+		return false, ErrTxNotFound
+	}
+
+	res, err := tx.NewUpdate().
+		Model(d).
+		Column(columns...).
+		WherePK().
+		Where("status = ?", models.DeliverySending).
+		Exec(ctx)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// RenewDeliveries продлевает отправку уведомлений ids, которые еще в статусе sending, отметкой времени now,
+// чтобы их не посчитали прерванными. Возвращает, сколько уведомлений продлено.
+func (s *Storage) RenewDeliveries(ctx context.Context, ids []int64, now time.Time) (int, error) {
+	tx, ok := txFromCtx(ctx)
+	if !ok {
+		return 0, ErrTxNotFound
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	res, err := tx.NewUpdate().
+		Model((*models.Delivery)(nil)).
+		Set("updated_at = ?", now).
+		Where("id IN (?)", bun.In(ids)).
+		Where("status = ?", models.DeliverySending).
+		Exec(ctx)
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	return int(n), err
+}
+
+// AbandonDeliveries переводит в dead уведомления, отправка которых началась раньше before и не завершилась,
+// например из-за падения сервиса. Такие уведомления могли дойти, поэтому повторно они не отправляются.
+// Возвращает, сколько уведомлений затронуто.
+func (s *Storage) AbandonDeliveries(ctx context.Context, before time.Time, reason string) (int, error) {
+	tx, ok := txFromCtx(ctx)
+	if !ok {
+		return 0, ErrTxNotFound
+	}
+
+	res, err := tx.NewUpdate().
+		Model((*models.Delivery)(nil)).
+		Set("status = ?", models.DeliveryDead).
+		Set("last_error = ?", reason).
+		Set("updated_at = NOW()").
+		Where("status = ?", models.DeliverySending).
+		Where("updated_at < ?", before).
+		Exec(ctx)
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	return int(n), err
+}
+
+// DeleteFinishedDeliveries удаляет до limit завершенных уведомлений: отправленных, пропущенных и dead,
+// которые последний раз изменялись раньше before. Возвращает, сколько уведомлений удалено.
+func (s *Storage) DeleteFinishedDeliveries(ctx context.Context, before time.Time, limit int) (int, error) {
+	tx, ok := txFromCtx(ctx)
+	if !ok {
+		return 0, ErrTxNotFound
+	}
+
+	finished := []models.DeliveryStatus{models.DeliverySent, models.DeliverySkipped, models.DeliveryDead}
+	res, err := tx.NewDelete().
+		Model((*models.Delivery)(nil)).
+		Where("id IN (?)", tx.NewSelect().
+			Model((*models.Delivery)(nil)).
+			Column("id").
+			Where("status IN (?)", bun.In(finished)).
+			Where("updated_at < ?", before).
+			Limit(limit)).
+		Exec(ctx)
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	return int(n), err
+}
@@ -0,0 +1,57 @@
+package postgres
+
+import (
+	"context"
+	"log"
+)
+
+// TryAdvisoryLock пытается взять сессионную advisory-блокировку key и возвращает, удерживает ли ее этот процесс.
+// Блокировка живет на отдельном соединении, поэтому Postgres снимает ее сам, если процесс упал или потерял связь
+// с базой. Повторный вызов для уже взятой блокировки проверяет, что соединение еще живо.
+func (s *Storage) TryAdvisoryLock(ctx context.Context, key int64) (bool, error) {
+	s.locksMu.Lock()
+	defer s.locksMu.Unlock()
+
+	if conn, ok := s.locks[key]; ok {
+		err := conn.PingContext(ctx)
+		if err == nil {
+			return true, nil
+		}
+		// Вместе с соединением потеряна и блокировка, пробуем взять ее заново
+		log.Printf("Соединение с advisory-блокировкой %d потеряно: %v", key, err)
+		_ = conn.Close()
+		delete(s.locks, key)
+	}
+
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return false, err
+	}
+	var locked bool
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock(?)", key).Scan(&locked); err != nil {
+		_ = conn.Close()
+		return false, err
+	}
+	if !locked {
+		_ = conn.Close()
+		return false, nil
+	}
+	s.locks[key] = conn
+	return true, nil
+}
+
+// AdvisoryUnlock снимает advisory-блокировку key, если этот процесс ее удерживает
+func (s *Storage) AdvisoryUnlock(ctx context.Context, key int64) error {
+	s.locksMu.Lock()
+	defer s.locksMu.Unlock()
+
+	conn, ok := s.locks[key]
+	if !ok {
+		return nil
+	}
+	delete(s.locks, key)
+	defer conn.Close()
+
+	_, err := conn.ExecContext(ctx, "SELECT pg_advisory_unlock(?)", key)
+	return err
+}
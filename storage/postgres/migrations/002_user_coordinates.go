@@ -0,0 +1,25 @@
+package migrations
+
+import (
+	"context"
+
+	"github.com/uptrace/bun"
+)
+
+func init() {
+	MigrationSet.MustRegister(func(ctx context.Context, db *bun.DB) error {
+		_, err := db.Exec(`
+        ALTER TABLE users
+            ADD COLUMN IF NOT EXISTS lat DOUBLE PRECISION,
+            ADD COLUMN IF NOT EXISTS lon DOUBLE PRECISION;
+`)
+		return err
+	}, func(ctx context.Context, db *bun.DB) error {
+		_, err := db.Exec(`
+        ALTER TABLE users
+            DROP COLUMN IF EXISTS lat,
+            DROP COLUMN IF EXISTS lon;
+`)
+		return err
+	})
+}
@@ -0,0 +1,27 @@
+package migrations
+
+import (
+	"context"
+
+	"github.com/uptrace/bun"
+)
+
+func init() {
+	MigrationSet.MustRegister(func(ctx context.Context, db *bun.DB) error {
+		_, err := db.Exec(`
+        ALTER TABLE users
+            ADD COLUMN IF NOT EXISTS show_air_quality BOOLEAN NOT NULL DEFAULT false,
+            ADD COLUMN IF NOT EXISTS aqi_alert_level SMALLINT NOT NULL DEFAULT 0,
+            ADD COLUMN IF NOT EXISTS last_aqi SMALLINT NOT NULL DEFAULT 0;
+`)
+		return err
+	}, func(ctx context.Context, db *bun.DB) error {
+		_, err := db.Exec(`
+        ALTER TABLE users
+            DROP COLUMN IF EXISTS show_air_quality,
+            DROP COLUMN IF EXISTS aqi_alert_level,
+            DROP COLUMN IF EXISTS last_aqi;
+`)
+		return err
+	})
+}
@@ -0,0 +1,29 @@
+package migrations
+
+import (
+	"context"
+
+	"github.com/uptrace/bun"
+)
+
+func init() {
+	MigrationSet.MustRegister(func(ctx context.Context, db *bun.DB) error {
+		_, err := db.Exec(`
+        CREATE TABLE IF NOT EXISTS weather_alerts_sent (
+            alert_id VARCHAR(64) NOT NULL,
+            telegram_id BIGINT NOT NULL,
+            expires_at TIMESTAMPTZ NOT NULL,
+            sent_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+            PRIMARY KEY (alert_id, telegram_id)
+        );
+
+        CREATE INDEX IF NOT EXISTS weather_alerts_sent_expires_at_idx ON weather_alerts_sent (expires_at);
+`)
+		return err
+	}, func(ctx context.Context, db *bun.DB) error {
+		_, err := db.Exec(`
+drop table weather_alerts_sent cascade;
+`)
+		return err
+	})
+}
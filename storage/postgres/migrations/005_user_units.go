@@ -0,0 +1,23 @@
+package migrations
+
+import (
+	"context"
+
+	"github.com/uptrace/bun"
+)
+
+func init() {
+	MigrationSet.MustRegister(func(ctx context.Context, db *bun.DB) error {
+		_, err := db.Exec(`
+        ALTER TABLE users
+            ADD COLUMN IF NOT EXISTS units VARCHAR(10) NOT NULL DEFAULT 'metric';
+`)
+		return err
+	}, func(ctx context.Context, db *bun.DB) error {
+		_, err := db.Exec(`
+        ALTER TABLE users
+            DROP COLUMN IF EXISTS units;
+`)
+		return err
+	})
+}
@@ -0,0 +1,49 @@
+package migrations
+
+import (
+	"context"
+
+	"github.com/uptrace/bun"
+)
+
+func init() {
+	MigrationSet.MustRegister(func(ctx context.Context, db *bun.DB) error {
+		_, err := db.Exec(`
+        ALTER TABLE users
+            ADD COLUMN IF NOT EXISTS language VARCHAR(8) NOT NULL DEFAULT 'ru';
+
+        UPDATE users SET update_interval = CASE update_interval
+            WHEN '30 секунд' THEN '30s'
+            WHEN '1 минута' THEN '1m'
+            WHEN '15 минут' THEN '15m'
+            WHEN '1 час' THEN '1h'
+            WHEN '6 часов' THEN '6h'
+            WHEN '12 часов' THEN '12h'
+            ELSE update_interval
+        END;
+
+        ALTER TABLE users
+            ALTER COLUMN update_interval SET DEFAULT '1h';
+`)
+		return err
+	}, func(ctx context.Context, db *bun.DB) error {
+		_, err := db.Exec(`
+        ALTER TABLE users
+            ALTER COLUMN update_interval SET DEFAULT '1 час';
+
+        UPDATE users SET update_interval = CASE update_interval
+            WHEN '30s' THEN '30 секунд'
+            WHEN '1m' THEN '1 минута'
+            WHEN '15m' THEN '15 минут'
+            WHEN '1h' THEN '1 час'
+            WHEN '6h' THEN '6 часов'
+            WHEN '12h' THEN '12 часов'
+            ELSE update_interval
+        END;
+
+        ALTER TABLE users
+            DROP COLUMN IF EXISTS language;
+`)
+		return err
+	})
+}
@@ -0,0 +1,55 @@
+package migrations
+
+import (
+	"context"
+
+	"github.com/uptrace/bun"
+)
+
+func init() {
+	MigrationSet.MustRegister(func(ctx context.Context, db *bun.DB) error {
+		_, err := db.Exec(`
+        CREATE TABLE IF NOT EXISTS subscriptions (
+            id BIGSERIAL PRIMARY KEY,
+            telegram_id BIGINT NOT NULL REFERENCES users (telegram_id) ON DELETE CASCADE,
+            city VARCHAR(100) NOT NULL,
+            lat DOUBLE PRECISION,
+            lon DOUBLE PRECISION,
+            schedule TEXT NOT NULL,
+            format VARCHAR(10) NOT NULL DEFAULT 'full',
+            enabled BOOLEAN NOT NULL DEFAULT TRUE,
+            last_aqi INTEGER NOT NULL DEFAULT 0,
+            created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+        );
+
+        CREATE INDEX IF NOT EXISTS subscriptions_telegram_id_idx ON subscriptions (telegram_id);
+
+        INSERT INTO subscriptions (telegram_id, city, lat, lon, schedule, last_aqi, created_at)
+        SELECT telegram_id, city, lat, lon, update_interval, last_aqi, created_at
+        FROM users
+        WHERE city IS NOT NULL AND city != '' AND update_interval != '';
+
+        ALTER TABLE users
+            DROP COLUMN IF EXISTS update_interval,
+            DROP COLUMN IF EXISTS last_aqi;
+`)
+		return err
+	}, func(ctx context.Context, db *bun.DB) error {
+		_, err := db.Exec(`
+        ALTER TABLE users
+            ADD COLUMN IF NOT EXISTS update_interval TEXT NOT NULL DEFAULT '1h',
+            ADD COLUMN IF NOT EXISTS last_aqi INTEGER NOT NULL DEFAULT 0;
+
+        UPDATE users SET update_interval = s.schedule, last_aqi = s.last_aqi
+        FROM (
+            SELECT DISTINCT ON (telegram_id) telegram_id, schedule, last_aqi
+            FROM subscriptions
+            ORDER BY telegram_id, id
+        ) s
+        WHERE users.telegram_id = s.telegram_id;
+
+        DROP TABLE IF EXISTS subscriptions;
+`)
+		return err
+	})
+}
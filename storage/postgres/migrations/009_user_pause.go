@@ -0,0 +1,27 @@
+package migrations
+
+import (
+	"context"
+
+	"github.com/uptrace/bun"
+)
+
+func init() {
+	MigrationSet.MustRegister(func(ctx context.Context, db *bun.DB) error {
+		_, err := db.Exec(`
+        ALTER TABLE users
+            ADD COLUMN IF NOT EXISTS paused BOOLEAN NOT NULL DEFAULT false,
+            ADD COLUMN IF NOT EXISTS paused_until TIMESTAMPTZ,
+            ADD COLUMN IF NOT EXISTS stopped BOOLEAN NOT NULL DEFAULT false;
+`)
+		return err
+	}, func(ctx context.Context, db *bun.DB) error {
+		_, err := db.Exec(`
+        ALTER TABLE users
+            DROP COLUMN IF EXISTS paused,
+            DROP COLUMN IF EXISTS paused_until,
+            DROP COLUMN IF EXISTS stopped;
+`)
+		return err
+	})
+}
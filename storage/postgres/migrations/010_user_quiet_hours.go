@@ -0,0 +1,29 @@
+package migrations
+
+import (
+	"context"
+
+	"github.com/uptrace/bun"
+)
+
+func init() {
+	MigrationSet.MustRegister(func(ctx context.Context, db *bun.DB) error {
+		_, err := db.Exec(`
+        ALTER TABLE users
+            ADD COLUMN IF NOT EXISTS quiet_from VARCHAR(5) NOT NULL DEFAULT '',
+            ADD COLUMN IF NOT EXISTS quiet_to VARCHAR(5) NOT NULL DEFAULT '',
+            ADD COLUMN IF NOT EXISTS quiet_mode VARCHAR(10) NOT NULL DEFAULT 'skip',
+            ADD COLUMN IF NOT EXISTS quiet_missed BOOLEAN NOT NULL DEFAULT false;
+`)
+		return err
+	}, func(ctx context.Context, db *bun.DB) error {
+		_, err := db.Exec(`
+        ALTER TABLE users
+            DROP COLUMN IF EXISTS quiet_from,
+            DROP COLUMN IF EXISTS quiet_to,
+            DROP COLUMN IF EXISTS quiet_mode,
+            DROP COLUMN IF EXISTS quiet_missed;
+`)
+		return err
+	})
+}
@@ -0,0 +1,29 @@
+package migrations
+
+import (
+	"context"
+
+	"github.com/uptrace/bun"
+)
+
+func init() {
+	MigrationSet.MustRegister(func(ctx context.Context, db *bun.DB) error {
+		// next_run_at существующих подписок заполняет сервис при запуске: расписания разбираются только в Go
+		_, err := db.Exec(`
+        ALTER TABLE subscriptions
+            ADD COLUMN IF NOT EXISTS next_run_at TIMESTAMPTZ;
+
+        CREATE INDEX IF NOT EXISTS subscriptions_next_run_at_idx
+            ON subscriptions (next_run_at) WHERE enabled;
+`)
+		return err
+	}, func(ctx context.Context, db *bun.DB) error {
+		_, err := db.Exec(`
+        DROP INDEX IF EXISTS subscriptions_next_run_at_idx;
+
+        ALTER TABLE subscriptions
+            DROP COLUMN IF EXISTS next_run_at;
+`)
+		return err
+	})
+}
@@ -0,0 +1,23 @@
+package migrations
+
+import (
+	"context"
+
+	"github.com/uptrace/bun"
+)
+
+func init() {
+	MigrationSet.MustRegister(func(ctx context.Context, db *bun.DB) error {
+		_, err := db.Exec(`
+        ALTER TABLE subscriptions
+            ADD COLUMN IF NOT EXISTS last_delivered_at TIMESTAMPTZ;
+`)
+		return err
+	}, func(ctx context.Context, db *bun.DB) error {
+		_, err := db.Exec(`
+        ALTER TABLE subscriptions
+            DROP COLUMN IF EXISTS last_delivered_at;
+`)
+		return err
+	})
+}
@@ -0,0 +1,41 @@
+package migrations
+
+import (
+	"context"
+
+	"github.com/uptrace/bun"
+)
+
+func init() {
+	MigrationSet.MustRegister(func(ctx context.Context, db *bun.DB) error {
+		// Уникальность (subscription_id, scheduled_at) не дает поставить одно срабатывание в очередь дважды
+		_, err := db.Exec(`
+        CREATE TABLE IF NOT EXISTS deliveries (
+            id BIGSERIAL PRIMARY KEY,
+            subscription_id BIGINT NOT NULL REFERENCES subscriptions (id) ON DELETE CASCADE,
+            telegram_id BIGINT NOT NULL,
+            scheduled_at TIMESTAMPTZ NOT NULL,
+            header TEXT NOT NULL DEFAULT '',
+            status VARCHAR(10) NOT NULL DEFAULT 'pending',
+            attempts INTEGER NOT NULL DEFAULT 0,
+            message_id INTEGER,
+            last_error TEXT NOT NULL DEFAULT '',
+            next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+            sent_at TIMESTAMPTZ,
+            created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+            updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+            UNIQUE (subscription_id, scheduled_at)
+        );
+
+        CREATE INDEX IF NOT EXISTS deliveries_telegram_id_idx ON deliveries (telegram_id, scheduled_at);
+        CREATE INDEX IF NOT EXISTS deliveries_pending_idx ON deliveries (next_attempt_at) WHERE status = 'pending';
+        CREATE INDEX IF NOT EXISTS deliveries_sending_idx ON deliveries (updated_at) WHERE status = 'sending';
+`)
+		return err
+	}, func(ctx context.Context, db *bun.DB) error {
+		_, err := db.Exec(`
+        DROP TABLE IF EXISTS deliveries;
+`)
+		return err
+	})
+}
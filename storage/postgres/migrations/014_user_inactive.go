@@ -0,0 +1,25 @@
+package migrations
+
+import (
+	"context"
+
+	"github.com/uptrace/bun"
+)
+
+func init() {
+	MigrationSet.MustRegister(func(ctx context.Context, db *bun.DB) error {
+		_, err := db.Exec(`
+        ALTER TABLE users
+            ADD COLUMN IF NOT EXISTS inactive_reason VARCHAR(20) NOT NULL DEFAULT '',
+            ADD COLUMN IF NOT EXISTS inactive_since TIMESTAMPTZ;
+`)
+		return err
+	}, func(ctx context.Context, db *bun.DB) error {
+		_, err := db.Exec(`
+        ALTER TABLE users
+            DROP COLUMN IF EXISTS inactive_reason,
+            DROP COLUMN IF EXISTS inactive_since;
+`)
+		return err
+	})
+}
@@ -0,0 +1,29 @@
+package migrations
+
+import (
+	"context"
+
+	"github.com/uptrace/bun"
+)
+
+func init() {
+	MigrationSet.MustRegister(func(ctx context.Context, db *bun.DB) error {
+		_, err := db.Exec(`
+        ALTER TABLE subscriptions
+            ADD COLUMN IF NOT EXISTS only_on_change BOOLEAN NOT NULL DEFAULT false,
+            ADD COLUMN IF NOT EXISTS temp_threshold DOUBLE PRECISION NOT NULL DEFAULT 3,
+            ADD COLUMN IF NOT EXISTS wind_threshold DOUBLE PRECISION NOT NULL DEFAULT 5,
+            ADD COLUMN IF NOT EXISTS last_sent JSONB;
+`)
+		return err
+	}, func(ctx context.Context, db *bun.DB) error {
+		_, err := db.Exec(`
+        ALTER TABLE subscriptions
+            DROP COLUMN IF EXISTS only_on_change,
+            DROP COLUMN IF EXISTS temp_threshold,
+            DROP COLUMN IF EXISTS wind_threshold,
+            DROP COLUMN IF EXISTS last_sent;
+`)
+		return err
+	})
+}
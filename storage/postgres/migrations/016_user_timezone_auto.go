@@ -0,0 +1,25 @@
+package migrations
+
+import (
+	"context"
+
+	"github.com/uptrace/bun"
+)
+
+func init() {
+	MigrationSet.MustRegister(func(ctx context.Context, db *bun.DB) error {
+		// Раньше часовой пояс определялся только как смещение от UTC, поэтому смещения считаем определенными автоматически
+		_, err := db.Exec(`
+        ALTER TABLE users
+            ADD COLUMN IF NOT EXISTS timezone_auto BOOLEAN NOT NULL DEFAULT FALSE;
+        UPDATE users SET timezone_auto = TRUE WHERE timezone LIKE '+%' OR timezone LIKE '-%';
+`)
+		return err
+	}, func(ctx context.Context, db *bun.DB) error {
+		_, err := db.Exec(`
+        ALTER TABLE users
+            DROP COLUMN IF EXISTS timezone_auto;
+`)
+		return err
+	})
+}
@@ -0,0 +1,23 @@
+package migrations
+
+import (
+	"context"
+
+	"github.com/uptrace/bun"
+)
+
+func init() {
+	MigrationSet.MustRegister(func(ctx context.Context, db *bun.DB) error {
+		// Индекс для удаления завершенных уведомлений старше срока хранения
+		_, err := db.Exec(`
+        CREATE INDEX IF NOT EXISTS deliveries_finished_idx ON deliveries (updated_at)
+            WHERE status IN ('sent', 'skipped', 'dead');
+`)
+		return err
+	}, func(ctx context.Context, db *bun.DB) error {
+		_, err := db.Exec(`
+        DROP INDEX IF EXISTS deliveries_finished_idx;
+`)
+		return err
+	})
+}
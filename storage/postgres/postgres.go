@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/ViolettaBykova/viot-tg-sirius/storage/postgres/migrations"
@@ -17,6 +18,9 @@ import (
 
 type Storage struct {
 	db *bun.DB
+
+	locksMu sync.Mutex
+	locks   map[int64]bun.Conn // Соединения, на которых удерживаются advisory-блокировки
 }
 
 func New(address, database, user, password string, pool int, envName string) (*Storage, error) {
@@ -41,7 +45,7 @@ func New(address, database, user, password string, pool int, envName string) (*S
 	if err := db.PingContext(context.Background()); err != nil {
 		return nil, err
 	}
-	return &Storage{db: db}, nil
+	return &Storage{db: db, locks: make(map[int64]bun.Conn)}, nil
 
 }
 
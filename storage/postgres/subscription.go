@@ -0,0 +1,201 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"time"
+
+	"github.com/ViolettaBykova/viot-tg-sirius/models"
+)
+
+// CreateSubscription сохраняет новую подписку и заполняет ее ID
+func (s *Storage) CreateSubscription(ctx context.Context, sub *models.Subscription) error {
+	tx, ok := txFromCtx(ctx)
+	if !ok {
+		return ErrTxNotFound
+	}
+
+	_, err := tx.NewInsert().Model(sub).Returning("id").Exec(ctx)
+	return err
+}
+
+// GetSubscription возвращает подписку вместе с ее владельцем
+func (s *Storage) GetSubscription(ctx context.Context, id int64) (*models.Subscription, error) {
+	tx, ok := txFromCtx(ctx)
+	if !ok {
+		return nil, ErrTxNotFound
+	}
+
+	var sub models.Subscription
+	err := tx.NewSelect().
+		Model(&sub).
+		Relation("User").
+		Where("subscription.id = ?", id).
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &sub, nil
+}
+
+// GetUserSubscriptions возвращает подписки пользователя в порядке создания
+func (s *Storage) GetUserSubscriptions(ctx context.Context, telegramID int64) ([]models.Subscription, error) {
+	tx, ok := txFromCtx(ctx)
+	if !ok {
+		return nil, ErrTxNotFound
+	}
+
+	var subs []models.Subscription
+	err := tx.NewSelect().
+		Model(&subs).
+		Where("telegram_id = ?", telegramID).
+		Order("id").
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return subs, nil
+}
+
+// GetEnabledSubscriptions возвращает включенные подписки активных пользователей, не остановивших уведомления,
+// вместе с их владельцами
+func (s *Storage) GetEnabledSubscriptions(ctx context.Context) ([]models.Subscription, error) {
+	tx, ok := txFromCtx(ctx)
+	if !ok {
+		return nil, ErrTxNotFound
+	}
+
+	var subs []models.Subscription
+	err := tx.NewSelect().
+		Model(&subs).
+		Relation("User").
+		Where("subscription.enabled").
+		Where(`NOT "user"."stopped"`).
+		Where(`"user"."inactive_reason" = ''`).
+		Order("subscription.id").
+		Scan(ctx)
+	if err != nil {
+		log.Printf("Ошибка при получении подписок: %v", err)
+		return nil, err
+	}
+	return subs, nil
+}
+
+// GetUnscheduledSubscriptions возвращает включенные подписки активных пользователей без времени следующего
+// уведомления вместе с их владельцами
+func (s *Storage) GetUnscheduledSubscriptions(ctx context.Context) ([]models.Subscription, error) {
+	tx, ok := txFromCtx(ctx)
+	if !ok {
+		return nil, ErrTxNotFound
+	}
+
+	var subs []models.Subscription
+	err := tx.NewSelect().
+		Model(&subs).
+		Relation("User").
+		Where("subscription.enabled").
+		Where("subscription.next_run_at IS NULL").
+		Where(`"user"."inactive_reason" = ''`).
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return subs, nil
+}
+
+// ClaimDueSubscriptions блокирует до limit включенных подписок активных пользователей, время уведомления которых наступило к now,
+// начиная с самых давних. Подписки остановленных пользователей и пользователей на паузе не выбираются:
+// их время уведомления рассчитывается заново после /resume или конца паузы. Строки, заблокированные другими транзакциями, пропускаются (SKIP LOCKED),
+// поэтому несколько экземпляров разбирают очередь параллельно, не получая одну подписку дважды.
+func (s *Storage) ClaimDueSubscriptions(ctx context.Context, now time.Time, limit int) ([]models.Subscription, error) {
+	tx, ok := txFromCtx(ctx)
+	if !ok {
+		return nil, ErrTxNotFound
+	}
+
+	var subs []models.Subscription
+	err := tx.NewSelect().
+		Model(&subs).
+		Relation("User").
+		Where("subscription.enabled").
+		Where(`NOT "user"."stopped"`).
+		Where(`NOT ("user"."paused" AND ("user"."paused_until" IS NULL OR "user"."paused_until" > ?))`, now).
+		Where(`"user"."inactive_reason" = ''`).
+		Where("subscription.next_run_at <= ?", now).
+		OrderExpr("subscription.next_run_at").
+		Limit(limit).
+		For("UPDATE OF subscription SKIP LOCKED").
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return subs, nil
+}
+
+// SetNextRuns сохраняет next_run_at нескольких подписок одним запросом
+func (s *Storage) SetNextRuns(ctx context.Context, subs []models.Subscription) error {
+	tx, ok := txFromCtx(ctx)
+	if !ok {
+		return ErrTxNotFound
+	}
+	if len(subs) == 0 {
+		return nil
+	}
+
+	_, err := tx.NewUpdate().
+		Model(&subs).
+		Column("next_run_at").
+		Bulk().
+		Exec(ctx)
+	return err
+}
+
+// UpdateSubscription сохраняет изменения подписки пользователя telegramID в колонках columns.
+// Возвращает sql.ErrNoRows, если у пользователя нет такой подписки.
+func (s *Storage) UpdateSubscription(ctx context.Context, sub *models.Subscription, columns ...string) error {
+	tx, ok := txFromCtx(ctx)
+	if !ok {
+		return ErrTxNotFound
+	}
+
+	res, err := tx.NewUpdate().
+		Model(sub).
+		Column(columns...).
+		Where("id = ? AND telegram_id = ?", sub.ID, sub.TelegramID).
+		Exec(ctx)
+	if err != nil {
+		return err
+	}
+	return requireRow(res)
+}
+
+// DeleteSubscription удаляет подписку пользователя.
+// Возвращает sql.ErrNoRows, если у пользователя нет такой подписки.
+func (s *Storage) DeleteSubscription(ctx context.Context, telegramID, id int64) error {
+	tx, ok := txFromCtx(ctx)
+	if !ok {
+		return ErrTxNotFound
+	}
+
+	res, err := tx.NewDelete().
+		Model((*models.Subscription)(nil)).
+		Where("id = ? AND telegram_id = ?", id, telegramID).
+		Exec(ctx)
+	if err != nil {
+		return err
+	}
+	return requireRow(res)
+}
+
+// requireRow возвращает sql.ErrNoRows, если запрос не затронул ни одной строки
+func requireRow(res sql.Result) error {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
@@ -5,9 +5,12 @@ import (
 	"database/sql"
 	"errors"
 	"log"
+	"time"
 
 	"github.com/ViolettaBykova/viot-tg-sirius/models"
 	"github.com/ViolettaBykova/viot-tg-sirius/models/scenes"
+	"github.com/ViolettaBykova/viot-tg-sirius/models/units"
+	"github.com/ViolettaBykova/viot-tg-sirius/pkg/i18n"
 )
 
 func (s *Storage) CreateUser(ctx context.Context, u *models.User) error {
@@ -85,8 +88,8 @@ func (s *Storage) SetCity(ctx context.Context, telegramID int64, city string) er
 	return err
 }
 
-// SetUpdateInterval устанавливает интервал обновлений для пользователя
-func (s *Storage) SetUpdateInterval(ctx context.Context, telegramID int64, interval string) error {
+// SetLocation устанавливает город и его координаты для пользователя
+func (s *Storage) SetLocation(ctx context.Context, telegramID int64, city string, lat, lon float64) error {
 	tx, ok := txFromCtx(ctx)
 	if !ok {
 		return ErrTxNotFound
@@ -94,26 +97,205 @@ func (s *Storage) SetUpdateInterval(ctx context.Context, telegramID int64, inter
 
 	_, err := tx.NewUpdate().
 		Model(&models.User{}).
-		Set("update_interval = ?", interval).
+		Set("city = ?", city).
+		Set("lat = ?", lat).
+		Set("lon = ?", lon).
+		Where("telegram_id = ?", telegramID).
+		Exec(ctx)
+	return err
+}
+
+// SetUnits устанавливает единицы измерения для пользователя
+func (s *Storage) SetUnits(ctx context.Context, telegramID int64, u units.Units) error {
+	tx, ok := txFromCtx(ctx)
+	if !ok {
+		return ErrTxNotFound
+	}
+
+	_, err := tx.NewUpdate().
+		Model(&models.User{}).
+		Set("units = ?", u).
+		Where("telegram_id = ?", telegramID).
+		Exec(ctx)
+	return err
+}
+
+// SetLanguage устанавливает язык сообщений для пользователя
+func (s *Storage) SetLanguage(ctx context.Context, telegramID int64, lang i18n.Lang) error {
+	tx, ok := txFromCtx(ctx)
+	if !ok {
+		return ErrTxNotFound
+	}
+
+	_, err := tx.NewUpdate().
+		Model(&models.User{}).
+		Set("language = ?", lang).
+		Where("telegram_id = ?", telegramID).
+		Exec(ctx)
+	return err
+}
+
+// SetTimezone устанавливает часовой пояс пользователя. auto — пояс определен по месту пользователя.
+func (s *Storage) SetTimezone(ctx context.Context, telegramID int64, timezone string, auto bool) error {
+	tx, ok := txFromCtx(ctx)
+	if !ok {
+		return ErrTxNotFound
+	}
+
+	_, err := tx.NewUpdate().
+		Model(&models.User{}).
+		Set("timezone = ?", timezone).
+		Set("timezone_auto = ?", auto).
+		Where("telegram_id = ?", telegramID).
+		Exec(ctx)
+	return err
+}
+
+// SetPause приостанавливает уведомления пользователя до until, nil — без срока.
+// paused=false снимает паузу.
+func (s *Storage) SetPause(ctx context.Context, telegramID int64, paused bool, until *time.Time) error {
+	tx, ok := txFromCtx(ctx)
+	if !ok {
+		return ErrTxNotFound
+	}
+
+	_, err := tx.NewUpdate().
+		Model(&models.User{}).
+		Set("paused = ?", paused).
+		Set("paused_until = ?", until).
+		Where("telegram_id = ?", telegramID).
+		Exec(ctx)
+	return err
+}
+
+// SetStopped останавливает или возобновляет уведомления пользователя
+func (s *Storage) SetStopped(ctx context.Context, telegramID int64, stopped bool) error {
+	tx, ok := txFromCtx(ctx)
+	if !ok {
+		return ErrTxNotFound
+	}
+
+	_, err := tx.NewUpdate().
+		Model(&models.User{}).
+		Set("stopped = ?", stopped).
+		Where("telegram_id = ?", telegramID).
+		Exec(ctx)
+	return err
+}
+
+// SetInactive отключает пользователя, которому бот не может писать, с причиной reason.
+// Пустая reason снова включает пользователя.
+func (s *Storage) SetInactive(ctx context.Context, telegramID int64, reason models.InactiveReason) error {
+	tx, ok := txFromCtx(ctx)
+	if !ok {
+		return ErrTxNotFound
+	}
+
+	q := tx.NewUpdate().
+		Model(&models.User{}).
+		Set("inactive_reason = ?", reason).
+		Where("telegram_id = ?", telegramID)
+	if reason == "" {
+		q = q.Set("inactive_since = NULL")
+	} else {
+		// Время отключения не меняется, если пользователь уже отключен
+		q = q.Set("inactive_since = COALESCE(inactive_since, NOW())")
+	}
+	_, err := q.Exec(ctx)
+	return err
+}
+
+// SetQuietHours задает тихие часы пользователя, пустые from и to — выключает их
+func (s *Storage) SetQuietHours(ctx context.Context, telegramID int64, from, to string) error {
+	tx, ok := txFromCtx(ctx)
+	if !ok {
+		return ErrTxNotFound
+	}
+
+	_, err := tx.NewUpdate().
+		Model(&models.User{}).
+		Set("quiet_from = ?", from).
+		Set("quiet_to = ?", to).
+		Where("telegram_id = ?", telegramID).
+		Exec(ctx)
+	return err
+}
+
+// SetQuietMode задает, что делать с обновлениями в тихие часы
+func (s *Storage) SetQuietMode(ctx context.Context, telegramID int64, mode models.QuietMode) error {
+	tx, ok := txFromCtx(ctx)
+	if !ok {
+		return ErrTxNotFound
+	}
+
+	_, err := tx.NewUpdate().
+		Model(&models.User{}).
+		Set("quiet_mode = ?", mode).
+		Where("telegram_id = ?", telegramID).
+		Exec(ctx)
+	return err
+}
+
+// SetQuietMissed отмечает, что в тихие часы были пропущены обновления
+func (s *Storage) SetQuietMissed(ctx context.Context, telegramID int64, missed bool) error {
+	tx, ok := txFromCtx(ctx)
+	if !ok {
+		return ErrTxNotFound
+	}
+
+	_, err := tx.NewUpdate().
+		Model(&models.User{}).
+		Set("quiet_missed = ?", missed).
 		Where("telegram_id = ?", telegramID).
 		Exec(ctx)
 	return err
 }
 
-func (s *Storage) GetAllUsersWithInterval(ctx context.Context) ([]models.User, error) {
+// GetQuietMissedUsers возвращает пользователей, которым нужно прислать сводку после тихих часов
+func (s *Storage) GetQuietMissedUsers(ctx context.Context) ([]models.User, error) {
 	tx, ok := txFromCtx(ctx)
 	if !ok {
 		return nil, ErrTxNotFound
 	}
+
 	var users []models.User
 	err := tx.NewSelect().
 		Model(&users).
-		Column("telegram_id", "update_interval").
-		Where("update_interval IS NOT NULL AND update_interval != ''").
+		Where("quiet_missed").
+		Where("inactive_reason = ''").
 		Scan(ctx)
 	if err != nil {
-		log.Printf("Ошибка при получении пользователей с интервалом: %v", err)
 		return nil, err
 	}
 	return users, nil
 }
+
+// SetShowAirQuality включает или выключает строку о качестве воздуха в обновлениях
+func (s *Storage) SetShowAirQuality(ctx context.Context, telegramID int64, show bool) error {
+	tx, ok := txFromCtx(ctx)
+	if !ok {
+		return ErrTxNotFound
+	}
+
+	_, err := tx.NewUpdate().
+		Model(&models.User{}).
+		Set("show_air_quality = ?", show).
+		Where("telegram_id = ?", telegramID).
+		Exec(ctx)
+	return err
+}
+
+// SetAQIAlertLevel устанавливает порог AQI для предупреждений
+func (s *Storage) SetAQIAlertLevel(ctx context.Context, telegramID int64, level int) error {
+	tx, ok := txFromCtx(ctx)
+	if !ok {
+		return ErrTxNotFound
+	}
+
+	_, err := tx.NewUpdate().
+		Model(&models.User{}).
+		Set("aqi_alert_level = ?", level).
+		Where("telegram_id = ?", telegramID).
+		Exec(ctx)
+	return err
+}